@@ -5,6 +5,7 @@ import (
 	"os"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sandrolain/gommander/pkg/config"
 	"github.com/sandrolain/gommander/pkg/fs"
 	"github.com/sandrolain/gommander/pkg/model"
 )
@@ -15,7 +16,13 @@ var leftWatcherSub *fs.DirWatcherSubscription
 var rightWatcherSub *fs.DirWatcherSubscription
 
 func main() {
-	m := model.InitialModel(func(path string, cb func()) error {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	m := model.InitialModel(cfg, func(path string, cb func()) error {
 		var err error
 		leftWatcherSub, err = fs.SubscribeWatcher(leftWatcherSub, path, func(eventPath string, err error) {
 			if err != nil {
@@ -43,8 +50,19 @@ func main() {
 
 	p = tea.NewProgram(m, tea.WithMouseAllMotion())
 
-	if _, err := p.Run(); err != nil {
+	final, err := p.Run()
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error starting app: %v", err)
 		os.Exit(1)
 	}
+
+	if err := model.SaveTabs(final); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving the tabs: %v\n", err)
+	}
+	if err := model.SaveFrecency(final); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving the visited directories: %v\n", err)
+	}
+	if err := model.RemoveTempFiles(final); err != nil {
+		fmt.Fprintf(os.Stderr, "Error removing the temporary files: %v\n", err)
+	}
 }
@@ -0,0 +1,544 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/bzip2"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Suffixes of the supported archives, the longest first
+var Suffixes = []string{".tar.gz", ".tar.bz2", ".tar.zst", ".tgz", ".tbz2", ".tbz", ".tzst", ".tar", ".zip"}
+
+// Supported reports whether the file name has the suffix of an archive
+// that can be browsed.
+func Supported(name string) bool {
+	return suffix(name) != ""
+}
+
+func suffix(name string) string {
+	lower := strings.ToLower(name)
+	for _, s := range Suffixes {
+		if strings.HasSuffix(lower, s) {
+			return s
+		}
+	}
+	return ""
+}
+
+// IsArchive reports whether the path is a file in a supported format, that
+// can be browsed.
+func IsArchive(p string) bool {
+	if !Supported(p) {
+		return false
+	}
+	info, err := os.Stat(p)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// Split splits a path inside an archive, like /tmp/a.zip/dir/file, into the
+// path of the archive and the slash-separated name of the entry. It returns
+// false for the paths outside of archives, the archives themselves included.
+func Split(p string) (string, string, bool) {
+	p = filepath.Clean(p)
+	inner := []string{}
+	for {
+		if info, err := os.Stat(p); err == nil {
+			if len(inner) > 0 && info.Mode().IsRegular() && Supported(p) {
+				// Reverse the components collected from the end
+				for i, j := 0, len(inner)-1; i < j; i, j = i+1, j-1 {
+					inner[i], inner[j] = inner[j], inner[i]
+				}
+				return p, strings.Join(inner, "/"), true
+			}
+			return "", "", false
+		}
+
+		parent := filepath.Dir(p)
+		if parent == p {
+			return "", "", false
+		}
+		inner = append(inner, filepath.Base(p))
+		p = parent
+	}
+}
+
+// entryInfo describes an entry of the archive, the size of the directories
+// is the total size of their content.
+type entryInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func (i *entryInfo) Name() string       { return i.name }
+func (i *entryInfo) Size() int64        { return i.size }
+func (i *entryInfo) Mode() fs.FileMode  { return i.mode }
+func (i *entryInfo) ModTime() time.Time { return i.modTime }
+func (i *entryInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i *entryInfo) Sys() any           { return nil }
+
+// Archive is the index of the entries of an archive.
+type Archive struct {
+	Path     string
+	modTime  time.Time
+	size     int64
+	entries  map[string]*entryInfo
+	children map[string][]string
+}
+
+var (
+	cacheMu sync.Mutex
+	cache   = map[string]*Archive{}
+)
+
+// Open reads the index of the archive, reusing the previous one if the file
+// did not change.
+func Open(p string) (*Archive, error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheMu.Lock()
+	a, ok := cache[p]
+	cacheMu.Unlock()
+	if ok && a.modTime.Equal(info.ModTime()) && a.size == info.Size() {
+		return a, nil
+	}
+
+	a = &Archive{
+		Path:     p,
+		modTime:  info.ModTime(),
+		size:     info.Size(),
+		entries:  map[string]*entryInfo{"": {name: filepath.Base(p), mode: fs.ModeDir | 0o555, modTime: info.ModTime()}},
+		children: map[string][]string{},
+	}
+	err = a.walk(func(name string, info fs.FileInfo, r io.Reader) error {
+		a.add(name, info)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading archive %v: %v", p, err)
+	}
+	a.index()
+
+	cacheMu.Lock()
+	cache[p] = a
+	cacheMu.Unlock()
+	return a, nil
+}
+
+// cleanName normalizes the name of an entry, returning false for the names
+// escaping the archive.
+func cleanName(name string) (string, bool) {
+	name = path.Clean(strings.TrimLeft(strings.ReplaceAll(name, "\\", "/"), "/"))
+	if name == "." || !fs.ValidPath(name) {
+		return "", false
+	}
+	return name, true
+}
+
+func (a *Archive) add(name string, info fs.FileInfo) {
+	mode := info.Mode()
+	if !mode.IsDir() && !mode.IsRegular() && mode&fs.ModeSymlink == 0 {
+		return
+	}
+	a.entries[name] = &entryInfo{name: path.Base(name), size: info.Size(), mode: mode, modTime: info.ModTime()}
+	if mode.IsDir() {
+		a.entries[name].size = 0
+	}
+}
+
+// index adds the directories missing from the archive, and computes the
+// children and the sizes of the directories.
+func (a *Archive) index() {
+	names := []string{}
+	for name := range a.entries {
+		names = append(names, name)
+	}
+
+	for _, name := range names {
+		for dir := path.Dir(name); dir != "." && name != ""; dir = path.Dir(dir) {
+			if _, ok := a.entries[dir]; !ok {
+				a.entries[dir] = &entryInfo{name: path.Base(dir), mode: fs.ModeDir | 0o755, modTime: a.modTime}
+			}
+		}
+	}
+
+	for name, info := range a.entries {
+		if name == "" {
+			continue
+		}
+		parent := path.Dir(name)
+		if parent == "." {
+			parent = ""
+		}
+		a.children[parent] = append(a.children[parent], name)
+
+		if info.IsDir() {
+			continue
+		}
+		for dir := path.Dir(name); ; dir = path.Dir(dir) {
+			if dir == "." {
+				dir = ""
+			}
+			a.entries[dir].size += info.size
+			if dir == "" {
+				break
+			}
+		}
+	}
+
+	for _, children := range a.children {
+		sort.Strings(children)
+	}
+}
+
+// Stat returns the entry with the given name, "" being the root.
+func (a *Archive) Stat(name string) (fs.FileInfo, error) {
+	info, ok := a.entries[name]
+	if !ok {
+		return nil, fs.ErrNotExist
+	}
+	return info, nil
+}
+
+// List returns the entries of a directory of the archive.
+func (a *Archive) List(dir string) ([]fs.FileInfo, error) {
+	info, ok := a.entries[dir]
+	if !ok {
+		return nil, fs.ErrNotExist
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%v is not a directory", dir)
+	}
+
+	res := []fs.FileInfo{}
+	for _, name := range a.children[dir] {
+		res = append(res, a.entries[name])
+	}
+	return res, nil
+}
+
+// errFound stops the walk once the entry is read
+var errFound = errors.New("found")
+
+// Open returns a reader of the content of a file of the archive.
+func (a *Archive) Open(name string) (io.ReadCloser, error) {
+	info, err := a.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("%v is not a regular file", name)
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		err := a.walk(func(entry string, info fs.FileInfo, r io.Reader) error {
+			if entry != name {
+				return nil
+			}
+			if _, err := io.Copy(pw, r); err != nil {
+				return err
+			}
+			return errFound
+		})
+		if err == nil {
+			err = fs.ErrNotExist
+		} else if err == errFound {
+			err = nil
+		}
+		pw.CloseWithError(err)
+	}()
+	return pr, nil
+}
+
+// Extract copies the entry, with its content if it is a directory, to the
+// destination directory.
+func (a *Archive) Extract(name string, dstDir string, overwrite bool) error {
+	info, err := a.Stat(name)
+	if err != nil {
+		return err
+	}
+
+	target := filepath.Join(dstDir, info.Name())
+	if !overwrite {
+		if _, err := os.Lstat(target); err == nil {
+			return fmt.Errorf("file %v already exists", target)
+		}
+	}
+	return a.extract(context.Background(), name, target, ConflictOverwrite, nil)
+}
+
+// Policies for the files of the archive already present in the destination
+const (
+	ConflictOverwrite = "overwrite"
+	ConflictSkip      = "skip"
+)
+
+// Conflicts returns the files of the archive that already exist in the
+// destination directory.
+func (a *Archive) Conflicts(dstDir string) []string {
+	res := []string{}
+	for name, info := range a.entries {
+		if name == "" || info.IsDir() {
+			continue
+		}
+		if _, err := os.Lstat(filepath.Join(dstDir, filepath.FromSlash(name))); err == nil {
+			res = append(res, name)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// ExtractAll extracts the content of the archive to the destination
+// directory, handling the existing files with the conflict policy.
+func (a *Archive) ExtractAll(ctx context.Context, dstDir string, conflict string, progress func(Progress)) error {
+	return a.extract(ctx, "", dstDir, conflict, progress)
+}
+
+// extract writes the entries under prefix to the target directory. The
+// entries resolving outside of it, through ".." elements or links already
+// present in the destination, are refused.
+func (a *Archive) extract(ctx context.Context, prefix string, target string, conflict string, progress func(Progress)) error {
+	info, err := a.Stat(prefix)
+	if err != nil {
+		return err
+	}
+
+	// The entries stay in the destination directory, the one holding the
+	// target when extracting a single entry
+	root := target
+	if prefix != "" {
+		root = filepath.Dir(target)
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return fmt.Errorf("error creating directory %v: %v", root, err)
+	}
+	root, err = filepath.EvalSymlinks(root)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		if err := mkdirInside(root, target); err != nil {
+			return err
+		}
+	}
+
+	r := &reporter{progress: progress, last: time.Now()}
+	r.current.Total = uint64(info.Size())
+
+	dirTimes := map[string]time.Time{}
+	err = a.walk(func(entry string, info fs.FileInfo, src io.Reader) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		rel := ""
+		if entry != prefix {
+			if prefix != "" && !strings.HasPrefix(entry, prefix+"/") {
+				return nil
+			}
+			rel = strings.TrimPrefix(entry, prefix+"/")
+		}
+		if rel != "" && !filepath.IsLocal(filepath.FromSlash(rel)) {
+			return nil
+		}
+		dst := filepath.Join(target, filepath.FromSlash(rel))
+
+		switch {
+		case info.IsDir():
+			if err := mkdirInside(root, dst); err != nil {
+				return err
+			}
+			dirTimes[dst] = info.ModTime()
+			return nil
+		case !info.Mode().IsRegular():
+			// Links and special files are not extracted
+			return nil
+		}
+
+		if err := mkdirInside(root, filepath.Dir(dst)); err != nil {
+			return err
+		}
+
+		r.current.Name = entry
+		if existing, err := os.Lstat(dst); err == nil {
+			if conflict == ConflictSkip {
+				r.current.Bytes += uint64(info.Size())
+				r.report(false)
+				return nil
+			}
+			// Replace the links instead of writing through them
+			if !existing.Mode().IsRegular() {
+				if err := os.Remove(dst); err != nil {
+					return fmt.Errorf("error replacing %v: %v", dst, err)
+				}
+			}
+		}
+
+		f, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm()|0o200)
+		if err != nil {
+			return fmt.Errorf("error creating file %v: %v", dst, err)
+		}
+		_, err = io.Copy(&progressWriter{w: f, r: r}, &ctxReader{ctx: ctx, r: src})
+		f.Close()
+		if err != nil {
+			os.Remove(dst)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("error extracting file %v: %v", entry, err)
+		}
+		r.current.Files++
+		r.report(false)
+		return os.Chtimes(dst, info.ModTime(), info.ModTime())
+	})
+	if err != nil {
+		return err
+	}
+
+	for dir, t := range dirTimes {
+		os.Chtimes(dir, t, t)
+	}
+	r.report(true)
+	return nil
+}
+
+// checkInside fails if the directory, once the links are resolved, is not
+// under root.
+func checkInside(root string, dir string) error {
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(root, resolved)
+	if err != nil || !filepath.IsLocal(rel) && rel != "." {
+		return fmt.Errorf("%v is outside of the destination directory", dir)
+	}
+	return nil
+}
+
+// mkdirInside creates the directory and its missing parents, failing if the
+// ones already present resolve outside of root through links.
+func mkdirInside(root string, dir string) error {
+	existing := dir
+	for {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			break
+		}
+		existing = parent
+	}
+	if err := checkInside(root, existing); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("error creating directory %v: %v", dir, err)
+	}
+	return checkInside(root, dir)
+}
+
+// walk calls fn for each entry of the archive with a reader of its content.
+func (a *Archive) walk(fn func(name string, info fs.FileInfo, r io.Reader) error) error {
+	if suffix(a.Path) == ".zip" {
+		return a.walkZip(fn)
+	}
+	return a.walkTar(fn)
+}
+
+func (a *Archive) walkZip(fn func(name string, info fs.FileInfo, r io.Reader) error) error {
+	zr, err := zip.OpenReader(a.Path)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		name, ok := cleanName(f.Name)
+		if !ok {
+			continue
+		}
+
+		var r io.ReadCloser = io.NopCloser(strings.NewReader(""))
+		if !f.FileInfo().IsDir() {
+			if r, err = f.Open(); err != nil {
+				return err
+			}
+		}
+		err := fn(name, f.FileInfo(), r)
+		r.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *Archive) walkTar(fn func(name string, info fs.FileInfo, r io.Reader) error) error {
+	f, err := os.Open(a.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	switch suffix(a.Path) {
+	case ".tar.gz", ".tgz":
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	case ".tar.bz2", ".tbz2", ".tbz":
+		r = bzip2.NewReader(f)
+	case ".tar.zst", ".tzst":
+		zr, err := zstd.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name, ok := cleanName(h.Name)
+		if !ok {
+			continue
+		}
+		if err := fn(name, h.FileInfo(), tr); err != nil {
+			return err
+		}
+	}
+}
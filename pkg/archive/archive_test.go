@@ -0,0 +1,234 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// entry is a file of a test archive, a directory when the name ends with /.
+type entry struct {
+	name    string
+	content string
+}
+
+func writeTar(t *testing.T, dir string, entries []entry) string {
+	t.Helper()
+	p := filepath.Join(dir, "test.tar")
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	tw := tar.NewWriter(f)
+	for _, e := range entries {
+		h := &tar.Header{Name: e.name, Mode: 0o644, Size: int64(len(e.content)), Typeflag: tar.TypeReg}
+		if strings.HasSuffix(e.name, "/") {
+			h.Mode, h.Typeflag = 0o755, tar.TypeDir
+		}
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func writeZip(t *testing.T, dir string, entries []entry) string {
+	t.Helper()
+	p := filepath.Join(dir, "test.zip")
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for _, e := range entries {
+		w, err := zw.Create(e.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(e.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// files lists the regular files under dir, relative to it.
+func files(t *testing.T, dir string) []string {
+	t.Helper()
+	names := []string{}
+	err := filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			rel, _ := filepath.Rel(dir, p)
+			names = append(names, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func extractAll(t *testing.T, p string, dst string, conflict string) error {
+	t.Helper()
+	a, err := Open(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a.ExtractAll(context.Background(), dst, conflict, nil)
+}
+
+func TestExtractNames(t *testing.T) {
+	entries := []entry{
+		{"../parent.txt", "x"},
+		{"dir/../../up.txt", "x"},
+		{"/abs/file.txt", "abs"},
+		{`..\back.txt`, "x"},
+		{`win\file.txt`, "win"},
+		{"dir/", ""},
+		{"dir/ok.txt", "ok"},
+	}
+
+	for _, write := range []func(*testing.T, string, []entry) string{writeTar, writeZip} {
+		tmp := t.TempDir()
+		dst := filepath.Join(tmp, "dst")
+		p := write(t, tmp, entries)
+
+		if err := extractAll(t, p, dst, ConflictOverwrite); err != nil {
+			t.Fatal(err)
+		}
+		want := []string{"abs/file.txt", "dir/ok.txt", "win/file.txt"}
+		if got := files(t, dst); strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("%v: extracted %v, want %v", filepath.Base(p), got, want)
+		}
+		for _, name := range []string{"parent.txt", "up.txt", "back.txt"} {
+			if _, err := os.Lstat(filepath.Join(tmp, name)); err == nil {
+				t.Errorf("%v: %v extracted outside of the destination", filepath.Base(p), name)
+			}
+		}
+	}
+}
+
+func TestExtractSymlinkedDir(t *testing.T) {
+	for _, entries := range [][]entry{
+		{{"link/file.txt", "x"}},
+		{{"link/", ""}},
+		{{"link/sub/", ""}},
+	} {
+		tmp := t.TempDir()
+		outside := filepath.Join(tmp, "outside")
+		dst := filepath.Join(tmp, "dst")
+		for _, dir := range []string{outside, dst} {
+			if err := os.Mkdir(dir, 0o755); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := os.Symlink(outside, filepath.Join(dst, "link")); err != nil {
+			t.Fatal(err)
+		}
+		p := writeTar(t, tmp, entries)
+
+		if err := extractAll(t, p, dst, ConflictOverwrite); err == nil {
+			t.Errorf("%v: extraction through a link out of the destination succeeded", entries[0].name)
+		}
+		if got, err := os.ReadDir(outside); err != nil || len(got) > 0 {
+			t.Errorf("%v: written outside of the destination: %v %v", entries[0].name, got, err)
+		}
+	}
+}
+
+func TestExtractConflicts(t *testing.T) {
+	tmp := t.TempDir()
+	dst := filepath.Join(tmp, "dst")
+	p := writeTar(t, tmp, []entry{{"a.txt", "new"}, {"b.txt", "new"}})
+	if err := os.Mkdir(dst, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dst, "a.txt"), []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := Open(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := a.Conflicts(dst); len(got) != 1 || got[0] != "a.txt" {
+		t.Errorf("Conflicts = %v, want [a.txt]", got)
+	}
+
+	for _, c := range []struct {
+		conflict string
+		want     string
+	}{
+		{ConflictSkip, "old"},
+		{ConflictOverwrite, "new"},
+	} {
+		if err := extractAll(t, p, dst, c.conflict); err != nil {
+			t.Fatal(err)
+		}
+		for name, want := range map[string]string{"a.txt": c.want, "b.txt": "new"} {
+			data, err := os.ReadFile(filepath.Join(dst, name))
+			if err != nil || string(data) != want {
+				t.Errorf("%v: %v = %q %v, want %q", c.conflict, name, data, err, want)
+			}
+		}
+	}
+}
+
+func TestCreateSkipsOutput(t *testing.T) {
+	tmp := t.TempDir()
+	src := filepath.Join(tmp, "src")
+	if err := os.Mkdir(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "a.txt"), []byte("a"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The archive is written into the directory being packed, twice to
+	// also find it there complete
+	dst := filepath.Join(src, "out.zip")
+	for i := 0; i < 2; i++ {
+		if err := Create(context.Background(), dst, []string{src}, FormatZip, LevelDefault, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a, err := Open(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	infos, err := a.List("src")
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, info := range infos {
+		names = append(names, info.Name())
+	}
+	if strings.Join(names, ",") != "a.txt" {
+		t.Errorf("archive holds %v, want [a.txt]", names)
+	}
+}
@@ -0,0 +1,340 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	FormatZip    = "zip"
+	FormatTar    = "tar"
+	FormatTarGz  = "tar.gz"
+	FormatTarZst = "tar.zst"
+)
+
+var Formats = []string{FormatZip, FormatTar, FormatTarGz, FormatTarZst}
+
+const (
+	LevelDefault = "default"
+	LevelFastest = "fastest"
+	LevelBest    = "best"
+	// LevelStore keeps the files uncompressed
+	LevelStore = "store"
+)
+
+// Levels returns the compression levels of the format, none for tar.
+func Levels(format string) []string {
+	switch format {
+	case FormatZip, FormatTarGz:
+		return []string{LevelDefault, LevelFastest, LevelBest, LevelStore}
+	case FormatTarZst:
+		return []string{LevelDefault, LevelFastest, LevelBest}
+	}
+	return nil
+}
+
+func flateLevel(level string) int {
+	switch level {
+	case LevelFastest:
+		return flate.BestSpeed
+	case LevelBest:
+		return flate.BestCompression
+	case LevelStore:
+		return flate.NoCompression
+	}
+	return flate.DefaultCompression
+}
+
+func zstdLevel(level string) zstd.EncoderLevel {
+	switch level {
+	case LevelFastest:
+		return zstd.SpeedFastest
+	case LevelBest:
+		return zstd.SpeedBestCompression
+	}
+	return zstd.SpeedDefault
+}
+
+// Progress reports the files and the bytes written so far, Name being the
+// current entry.
+type Progress struct {
+	Files int
+	Bytes uint64
+	Total uint64
+	Name  string
+}
+
+// Reports are sent at most once per interval
+const progressInterval = 100 * time.Millisecond
+
+type reporter struct {
+	progress func(Progress)
+	current  Progress
+	last     time.Time
+}
+
+func (r *reporter) report(force bool) {
+	if r.progress == nil || (!force && time.Since(r.last) < progressInterval) {
+		return
+	}
+	r.last = time.Now()
+	r.progress(r.current)
+}
+
+// progressWriter counts the bytes written to the reporter.
+type progressWriter struct {
+	w io.Writer
+	r *reporter
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.r.current.Bytes += uint64(n)
+	w.r.report(false)
+	return n, err
+}
+
+// ctxReader stops reading large files as soon as the context is done.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+// entryWriter adds the entries to an archive being created.
+type entryWriter interface {
+	add(name string, path string, info fs.FileInfo) (io.Writer, error)
+	Close() error
+}
+
+type zipWriter struct {
+	zw     *zip.Writer
+	method uint16
+}
+
+func (w *zipWriter) add(name string, path string, info fs.FileInfo) (io.Writer, error) {
+	h, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return nil, err
+	}
+	h.Name = name
+	if info.IsDir() {
+		h.Name += "/"
+		h.Method = zip.Store
+	} else {
+		h.Method = w.method
+	}
+
+	e, err := w.zw.CreateHeader(h)
+	if err != nil {
+		return nil, err
+	}
+	if info.Mode()&fs.ModeSymlink != 0 {
+		// The target of the links is stored as their content
+		target, err := os.Readlink(path)
+		if err != nil {
+			return nil, err
+		}
+		_, err = io.WriteString(e, target)
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, nil
+	}
+	return e, nil
+}
+
+func (w *zipWriter) Close() error {
+	return w.zw.Close()
+}
+
+type tarWriter struct {
+	tw      *tar.Writer
+	closers []io.Closer
+}
+
+func (w *tarWriter) add(name string, path string, info fs.FileInfo) (io.Writer, error) {
+	link := ""
+	if info.Mode()&fs.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return nil, err
+		}
+		link = target
+	}
+
+	h, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return nil, err
+	}
+	h.Name = name
+	if info.IsDir() {
+		h.Name += "/"
+	}
+	if err := w.tw.WriteHeader(h); err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, nil
+	}
+	return w.tw, nil
+}
+
+func (w *tarWriter) Close() error {
+	err := w.tw.Close()
+	// Close the compressors from the inner one
+	for i := len(w.closers) - 1; i >= 0; i-- {
+		if cerr := w.closers[i].Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+func newEntryWriter(out io.Writer, format string, level string) (entryWriter, error) {
+	switch format {
+	case FormatZip:
+		zw := zip.NewWriter(out)
+		zw.RegisterCompressor(zip.Deflate, func(w io.Writer) (io.WriteCloser, error) {
+			return flate.NewWriter(w, flateLevel(level))
+		})
+		method := zip.Deflate
+		if level == LevelStore {
+			method = zip.Store
+		}
+		return &zipWriter{zw: zw, method: method}, nil
+	case FormatTar:
+		return &tarWriter{tw: tar.NewWriter(out)}, nil
+	case FormatTarGz:
+		gw, err := gzip.NewWriterLevel(out, flateLevel(level))
+		if err != nil {
+			return nil, err
+		}
+		return &tarWriter{tw: tar.NewWriter(gw), closers: []io.Closer{gw}}, nil
+	case FormatTarZst:
+		zw, err := zstd.NewWriter(out, zstd.WithEncoderLevel(zstdLevel(level)))
+		if err != nil {
+			return nil, err
+		}
+		return &tarWriter{tw: tar.NewWriter(zw), closers: []io.Closer{zw}}, nil
+	}
+	return nil, fmt.Errorf("unknown archive format %v", format)
+}
+
+// Create packs the files and directories in the archive dst, the entries
+// being named relative to the directories containing them. The archive is
+// written to a temporary file and renamed once complete.
+func Create(ctx context.Context, dst string, paths []string, format string, level string, progress func(Progress)) error {
+	r := &reporter{progress: progress, last: time.Now()}
+	for _, p := range paths {
+		filepath.WalkDir(p, func(path string, d fs.DirEntry, err error) error {
+			if err == nil && d.Type().IsRegular() {
+				if info, err := d.Info(); err == nil {
+					r.current.Total += uint64(info.Size())
+				}
+			}
+			return nil
+		})
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".*.part")
+	if err != nil {
+		return fmt.Errorf("error creating archive: %v", err)
+	}
+	tmp := f.Name()
+	f.Chmod(0o644)
+	defer func() {
+		f.Close()
+		os.Remove(tmp)
+	}()
+
+	w, err := newEntryWriter(f, format, level)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range paths {
+		base := filepath.Dir(p)
+		err := filepath.WalkDir(p, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			// Do not pack the archive into itself
+			if path == dst || path == tmp {
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() && !info.Mode().IsRegular() && info.Mode()&fs.ModeSymlink == 0 {
+				// Special files are not packed
+				return nil
+			}
+
+			rel, err := filepath.Rel(base, path)
+			if err != nil {
+				return err
+			}
+			r.current.Name = rel
+
+			e, err := w.add(filepath.ToSlash(rel), path, info)
+			if err != nil {
+				return fmt.Errorf("error adding %v: %v", path, err)
+			}
+			if e == nil {
+				return nil
+			}
+
+			src, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(&progressWriter{w: e, r: r}, &ctxReader{ctx: ctx, r: src})
+			src.Close()
+			if err != nil {
+				return fmt.Errorf("error adding %v: %v", path, err)
+			}
+			r.current.Files++
+			r.report(false)
+			return nil
+		})
+		if err != nil {
+			w.Close()
+			return err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("error writing archive: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("error writing archive: %v", err)
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		return fmt.Errorf("error creating archive: %v", err)
+	}
+	r.report(true)
+	return nil
+}
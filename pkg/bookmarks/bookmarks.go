@@ -0,0 +1,175 @@
+package bookmarks
+
+import (
+	"bufio"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sandrolain/gommander/pkg/state"
+)
+
+const stateFile = "bookmarks.json"
+
+// Shortcuts are the keys assigned to the bookmarks, in order.
+const Shortcuts = "123456789abcdefghijklmnopqrstuvwxyz0"
+
+type Bookmark struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	Key  string `json:"key,omitempty"`
+}
+
+// List is the hotlist, in the order it is shown.
+type List []Bookmark
+
+func Load() (List, error) {
+	var l List
+	err := state.Load(stateFile, &l)
+	return l, err
+}
+
+func Save(l List) error {
+	return state.Save(stateFile, l)
+}
+
+// ValidKey reports whether the key can be the shortcut of a bookmark.
+func ValidKey(key string) bool {
+	return len(key) == 1 && strings.Contains(Shortcuts, key)
+}
+
+// FindKey returns the index of the bookmark with the shortcut.
+func (l List) FindKey(key string) (int, bool) {
+	for i, b := range l {
+		if b.Key == key {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// FindPath returns the index of the bookmark of the path.
+func (l List) FindPath(path string) (int, bool) {
+	for i, b := range l {
+		if b.Path == path {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// FreeKey returns the first shortcut not in use, "" when all are.
+func (l List) FreeKey() string {
+	for _, r := range Shortcuts {
+		if _, ok := l.FindKey(string(r)); !ok {
+			return string(r)
+		}
+	}
+	return ""
+}
+
+// Add appends a bookmark with the first free shortcut.
+func (l List) Add(name string, path string) List {
+	return append(l, Bookmark{Name: name, Path: path, Key: l.FreeKey()})
+}
+
+// Import appends the bookmarks whose path is not in the list yet, returning
+// the number of the ones added.
+func (l List) Import(bookmarks []Bookmark) (List, int) {
+	added := 0
+	for _, b := range bookmarks {
+		if _, ok := l.FindPath(b.Path); ok {
+			continue
+		}
+		l = l.Add(b.Name, b.Path)
+		added++
+	}
+	return l, added
+}
+
+// GTK returns the bookmarks of the GTK file chooser, the local directories
+// and the SFTP ones.
+func GTK() ([]Bookmark, error) {
+	bookmarks := []Bookmark{}
+	for _, path := range gtkFiles() {
+		f, err := os.Open(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			if b, ok := parseGTK(scanner.Text()); ok {
+				bookmarks = append(bookmarks, b)
+			}
+		}
+		f.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return bookmarks, nil
+}
+
+func gtkFiles() []string {
+	config := os.Getenv("XDG_CONFIG_HOME")
+	home, _ := os.UserHomeDir()
+	if config == "" && home != "" {
+		config = filepath.Join(home, ".config")
+	}
+
+	files := []string{}
+	if config != "" {
+		files = append(files, filepath.Join(config, "gtk-3.0", "bookmarks"))
+	}
+	if home != "" {
+		files = append(files, filepath.Join(home, ".gtk-bookmarks"))
+	}
+	return files
+}
+
+// parseGTK parses a line like "file:///home/user/Some%20Dir Label", the
+// label being optional.
+func parseGTK(line string) (Bookmark, bool) {
+	uri, label, _ := strings.Cut(strings.TrimSpace(line), " ")
+	u, err := url.Parse(uri)
+	if err != nil {
+		return Bookmark{}, false
+	}
+
+	var path string
+	switch u.Scheme {
+	case "file":
+		path = filepath.Clean(u.Path)
+	case "sftp":
+		host := u.Host
+		if u.User != nil {
+			host = u.User.Username() + "@" + host
+		}
+		path = "sftp://" + host + u.Path
+	default:
+		return Bookmark{}, false
+	}
+
+	if label == "" {
+		label = filepath.Base(path)
+	}
+	return Bookmark{Name: label, Path: path}, true
+}
+
+// CDPath returns the absolute directories of $CDPATH.
+func CDPath() []Bookmark {
+	bookmarks := []Bookmark{}
+	for _, dir := range filepath.SplitList(os.Getenv("CDPATH")) {
+		if !filepath.IsAbs(dir) {
+			continue
+		}
+		dir = filepath.Clean(dir)
+		bookmarks = append(bookmarks, Bookmark{Name: filepath.Base(dir), Path: dir})
+	}
+	return bookmarks
+}
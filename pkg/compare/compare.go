@@ -0,0 +1,136 @@
+package compare
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"io"
+	"os"
+	"time"
+)
+
+type Status int
+
+const (
+	// None is used for the directories present in both panels, their
+	// content is not compared
+	None Status = iota
+	OnlyLeft
+	OnlyRight
+	LeftNewer
+	RightNewer
+	Identical
+	Different
+)
+
+const (
+	BySizeTime = "size-time"
+	ByContent  = "content"
+)
+
+// Entry is a file listed in a panel.
+type Entry struct {
+	Path    string
+	Dir     bool
+	Size    uint64
+	ModTime time.Time
+}
+
+// Dirs compares the entries of two directories by name. With BySizeTime
+// the files with the same size and modification time are identical, with
+// ByContent the files with the same size are hashed. The differing files
+// are reported as newer or older by their modification time.
+func Dirs(ctx context.Context, left map[string]Entry, right map[string]Entry, mode string) (map[string]Status, error) {
+	res := map[string]Status{}
+	for name, l := range left {
+		r, ok := right[name]
+		if !ok {
+			res[name] = OnlyLeft
+			continue
+		}
+
+		status, err := entries(ctx, l, r, mode)
+		if err != nil {
+			return nil, err
+		}
+		res[name] = status
+	}
+	for name := range right {
+		if _, ok := left[name]; !ok {
+			res[name] = OnlyRight
+		}
+	}
+	return res, nil
+}
+
+func entries(ctx context.Context, l Entry, r Entry, mode string) (Status, error) {
+	if l.Dir && r.Dir {
+		return None, nil
+	}
+	if l.Dir != r.Dir {
+		return Different, nil
+	}
+
+	// Compare to the second, as some file systems do not store fractions
+	lt := l.ModTime.Truncate(time.Second)
+	rt := r.ModTime.Truncate(time.Second)
+
+	if l.Size == r.Size {
+		if mode == ByContent {
+			same, err := sameContent(ctx, l.Path, r.Path)
+			if err != nil {
+				return None, err
+			}
+			if same {
+				return Identical, nil
+			}
+		} else if lt.Equal(rt) {
+			return Identical, nil
+		}
+	}
+
+	switch {
+	case lt.After(rt):
+		return LeftNewer, nil
+	case rt.After(lt):
+		return RightNewer, nil
+	}
+	return Different, nil
+}
+
+func sameContent(ctx context.Context, a string, b string) (bool, error) {
+	ha, err := hashFile(ctx, a)
+	if err != nil {
+		return false, err
+	}
+	hb, err := hashFile(ctx, b)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(ha, hb), nil
+}
+
+func hashFile(ctx context.Context, path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	buf := make([]byte, 64*1024)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		n, err := f.Read(buf)
+		h.Write(buf[:n])
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return h.Sum(nil), nil
+}
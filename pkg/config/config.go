@@ -0,0 +1,346 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/sandrolain/gommander/pkg/icons"
+	"github.com/sandrolain/gommander/pkg/opener"
+	"github.com/sandrolain/gommander/pkg/rows"
+	"github.com/sandrolain/gommander/pkg/theme"
+	"github.com/sandrolain/gommander/pkg/vfs"
+)
+
+const (
+	appName  = "gommander"
+	fileName = "config.toml"
+)
+
+type Column struct {
+	Key   string `toml:"key" json:"key"`
+	Width int    `toml:"width" json:"width,omitempty"`
+}
+
+type Confirm struct {
+	Copy   bool `toml:"copy"`
+	Move   bool `toml:"move"`
+	Delete bool `toml:"delete"`
+	Trash  bool `toml:"trash"`
+}
+
+type Icons struct {
+	Enabled    bool              `toml:"enabled"`
+	Style      string            `toml:"style"`
+	Types      map[string]string `toml:"types"`
+	Names      map[string]string `toml:"names"`
+	Extensions map[string]string `toml:"extensions"`
+}
+
+// IconSet returns the icon set of the configured style with the overrides
+// applied.
+func (cfg Config) IconSet() icons.Set {
+	set := icons.New(cfg.Icons.Style)
+	set.Override(cfg.Icons.Types, cfg.Icons.Names, cfg.Icons.Extensions)
+	return set
+}
+
+// VisibleColumns returns the columns of the panels, with the icon column
+// first when the icons are enabled and it is not listed.
+func (cfg Config) VisibleColumns() []Column {
+	if cfg.Icons.Enabled && !hasColumn(cfg.Columns, "icon") {
+		return append([]Column{{Key: "icon"}}, cfg.Columns...)
+	}
+	return cfg.Columns
+}
+
+func hasColumn(columns []Column, key string) bool {
+	for _, col := range columns {
+		if col.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+type DirSize struct {
+	// Auto computes the size of the directories when they are listed
+	Auto    bool `toml:"auto"`
+	Workers int  `toml:"workers"`
+}
+
+type Config struct {
+	Keys    map[string]string     `toml:"keys"`
+	Theme   theme.Theme           `toml:"theme"`
+	Columns []Column              `toml:"columns"`
+	Icons   Icons                 `toml:"icons"`
+	Sort    rows.SortOptions      `toml:"sort"`
+	Format  rows.FormatOptions    `toml:"format"`
+	DirSize DirSize               `toml:"dir_size"`
+	Confirm Confirm               `toml:"confirm"`
+	Editor  opener.Editor         `toml:"editor"`
+	Openers []opener.Rule         `toml:"openers"`
+	Remotes map[string]vfs.Remote `toml:"remotes"`
+}
+
+func Default() Config {
+	columns := []Column{}
+	for _, key := range rows.DefaultColumns {
+		columns = append(columns, Column{Key: key})
+	}
+
+	return Config{
+		Keys:    DefaultKeys(),
+		Theme:   theme.Default(),
+		Columns: columns,
+		Icons:   Icons{Style: icons.StyleNerd},
+		Sort:    rows.DefaultSortOptions(),
+		Format:  rows.DefaultFormatOptions(),
+		DirSize: DirSize{Workers: 4},
+		Confirm: Confirm{
+			Copy:   true,
+			Delete: true,
+			Trash:  true,
+		},
+	}
+}
+
+// Path returns the path of the configuration file, under
+// $XDG_CONFIG_HOME/gommander on Linux.
+func Path() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, appName, fileName), nil
+}
+
+// Load reads the configuration file, returning the defaults if it does
+// not exist. Values not set in the file keep their default.
+func Load() (Config, error) {
+	path, err := Path()
+	if err != nil {
+		return Default(), nil
+	}
+
+	cfg, err := LoadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Default(), nil
+	}
+	return cfg, err
+}
+
+func LoadFile(path string) (Config, error) {
+	cfg := Default()
+
+	// Keys are decoded apart, so they are merged with the defaults instead
+	// of replacing them
+	var file struct {
+		Config
+		Keys map[string]string `toml:"keys"`
+	}
+	file.Config = cfg
+
+	meta, err := toml.DecodeFile(path, &file)
+	if err != nil {
+		var pe toml.ParseError
+		if errors.As(err, &pe) {
+			return cfg, fmt.Errorf("%s: %s", path, pe.ErrorWithPosition())
+		}
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+
+	cfg = file.Config
+	cfg.Keys = DefaultKeys()
+	for action, key := range file.Keys {
+		cfg.Keys[action] = key
+	}
+
+	errs := []error{}
+	for _, key := range meta.Undecoded() {
+		errs = append(errs, fmt.Errorf("unknown option %q", key.String()))
+	}
+	errs = append(errs, validateKeys(file.Keys, cfg.Keys)...)
+	errs = append(errs, cfg.Theme.Validate()...)
+	if cfg.Theme.Dircolors != "" {
+		if _, err := os.Stat(cfg.Theme.Dircolors); err != nil {
+			errs = append(errs, fmt.Errorf("theme.dircolors: %v", err))
+		}
+	}
+	errs = append(errs, validateColumns(cfg.Columns)...)
+	errs = append(errs, validateIcons(cfg.Icons)...)
+	errs = append(errs, validateOpeners(cfg)...)
+	errs = append(errs, validateRemotes(cfg.Remotes)...)
+
+	if !rows.ValidSortKey(cfg.Sort.By) {
+		errs = append(errs, fmt.Errorf("sort.by: unknown sort key %q, expected one of %s", cfg.Sort.By, strings.Join(rows.SortKeys, ", ")))
+	}
+
+	if !rows.ValidTimeFormat(cfg.Format.Time) {
+		errs = append(errs, fmt.Errorf("format.time: invalid format %q, expected one of %s or a Go time layout", cfg.Format.Time, strings.Join(rows.TimeFormats, ", ")))
+	}
+	if !rows.ValidSizeFormat(cfg.Format.Size) {
+		errs = append(errs, fmt.Errorf("format.size: unknown format %q, expected one of %s", cfg.Format.Size, strings.Join(rows.SizeFormats, ", ")))
+	}
+
+	if cfg.DirSize.Workers < 1 {
+		errs = append(errs, fmt.Errorf("dir_size.workers: must be at least 1"))
+	}
+
+	if len(errs) > 0 {
+		msgs := make([]string, len(errs))
+		for i, err := range errs {
+			msgs[i] = "  " + err.Error()
+		}
+		return cfg, fmt.Errorf("%s:\n%s", path, strings.Join(msgs, "\n"))
+	}
+
+	return cfg, nil
+}
+
+func validateKeys(custom map[string]string, keys map[string]string) []error {
+	errs := []error{}
+	defaults := DefaultKeys()
+
+	actions := make([]string, 0, len(defaults))
+	for action := range defaults {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+
+	customActions := make([]string, 0, len(custom))
+	for action := range custom {
+		customActions = append(customActions, action)
+	}
+	sort.Strings(customActions)
+
+	for _, action := range customActions {
+		key := custom[action]
+		if _, ok := defaults[action]; !ok {
+			errs = append(errs, fmt.Errorf("keys.%s: unknown action, expected one of %s", action, strings.Join(actions, ", ")))
+		}
+		if strings.TrimSpace(key) == "" {
+			errs = append(errs, fmt.Errorf("keys.%s: key cannot be empty", action))
+		}
+	}
+
+	// Report each conflict once, naming the actions sharing the key
+	bound := map[string][]string{}
+	boundKeys := []string{}
+	for _, action := range actions {
+		if _, ok := bound[keys[action]]; !ok {
+			boundKeys = append(boundKeys, keys[action])
+		}
+		bound[keys[action]] = append(bound[keys[action]], action)
+	}
+	for _, key := range boundKeys {
+		names := bound[key]
+		if len(names) > 1 && key != "" {
+			errs = append(errs, fmt.Errorf("keys: %q is bound to more than one action: %s", key, strings.Join(names, ", ")))
+		}
+	}
+
+	return errs
+}
+
+func validateColumns(columns []Column) []error {
+	errs := []error{}
+	seen := map[string]bool{}
+	hasName := false
+
+	for i, col := range columns {
+		if _, ok := rows.FindColumn(col.Key); !ok {
+			errs = append(errs, fmt.Errorf("columns[%d]: unknown column %q, expected one of %s", i, col.Key, strings.Join(rows.ColumnKeys(), ", ")))
+		}
+		if seen[col.Key] {
+			errs = append(errs, fmt.Errorf("columns[%d]: column %q is repeated", i, col.Key))
+		}
+		if col.Width < 0 {
+			errs = append(errs, fmt.Errorf("columns[%d]: width cannot be negative", i))
+		}
+		seen[col.Key] = true
+		hasName = hasName || col.Key == "name"
+	}
+
+	if !hasName {
+		errs = append(errs, fmt.Errorf("columns: the name column is required"))
+	}
+
+	return errs
+}
+
+func validateIcons(cfg Icons) []error {
+	errs := []error{}
+	if !icons.ValidStyle(cfg.Style) {
+		errs = append(errs, fmt.Errorf("icons.style: unknown style %q, expected one of %s", cfg.Style, strings.Join(icons.Styles, ", ")))
+	}
+
+	types := make([]string, 0, len(cfg.Types))
+	for key := range cfg.Types {
+		types = append(types, key)
+	}
+	sort.Strings(types)
+	for _, key := range types {
+		if !icons.ValidType(key) {
+			errs = append(errs, fmt.Errorf("icons.types.%s: unknown type, expected one of %s", key, strings.Join(icons.TypeKeys, ", ")))
+		}
+	}
+
+	return errs
+}
+
+func validateOpeners(cfg Config) []error {
+	errs := []error{}
+
+	for i, rule := range cfg.Openers {
+		if rule.Command == "" {
+			errs = append(errs, fmt.Errorf("openers[%d]: command is required", i))
+		} else if _, err := opener.SplitArgs(rule.Command); err != nil {
+			errs = append(errs, fmt.Errorf("openers[%d]: %v", i, err))
+		}
+		if len(rule.Match) == 0 {
+			errs = append(errs, fmt.Errorf("openers[%d]: match is required", i))
+		}
+	}
+
+	if _, err := opener.SplitArgs(cfg.Editor.Command); err != nil {
+		errs = append(errs, fmt.Errorf("editor.command: %v", err))
+	}
+
+	return errs
+}
+
+func validateRemotes(remotes map[string]vfs.Remote) []error {
+	errs := []error{}
+
+	names := []string{}
+	for name := range remotes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		r := remotes[name]
+		switch r.Type {
+		case vfs.RemoteWebDAV:
+			if r.URL == "" {
+				errs = append(errs, fmt.Errorf("remotes.%s: url is required", name))
+			}
+		case vfs.RemoteS3:
+			if r.Bucket == "" {
+				errs = append(errs, fmt.Errorf("remotes.%s: bucket is required", name))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("remotes.%s: unknown type %q, expected one of %s", name, r.Type, strings.Join(vfs.RemoteTypes, ", ")))
+		}
+		if strings.ContainsAny(name, "/:@") {
+			errs = append(errs, fmt.Errorf("remotes.%s: the name cannot contain '/', ':' or '@'", name))
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadWithoutFile(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load without a configuration file: %v", err)
+	}
+	if cfg.Keys["quit"] != DefaultKeys()["quit"] || cfg.DirSize.Workers != Default().DirSize.Workers {
+		t.Errorf("Load without a configuration file = %+v, want the defaults", cfg)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), fileName)
+	content := "[keys]\nquit = \"ctrl+q\"\n\n[dir_size]\nworkers = 2\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Keys["quit"] != "ctrl+q" || cfg.DirSize.Workers != 2 {
+		t.Errorf("quit = %q, workers = %v, want ctrl+q and 2", cfg.Keys["quit"], cfg.DirSize.Workers)
+	}
+	if cfg.Keys["copy"] != DefaultKeys()["copy"] {
+		t.Errorf("copy = %q, want the default %q", cfg.Keys["copy"], DefaultKeys()["copy"])
+	}
+}
+
+func TestLoadFileErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), fileName)
+	if err := os.WriteFile(path, []byte("unknown = 1\n\n[dir_size]\nworkers = 0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadFile(path)
+	if err == nil {
+		t.Fatal("LoadFile of an invalid file succeeded")
+	}
+	for _, want := range []string{`unknown option "unknown"`, "dir_size.workers"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("LoadFile error %q does not mention %q", err, want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package config
+
+// DefaultKeys maps the actions to their default key bindings.
+func DefaultKeys() map[string]string {
+	return map[string]string{
+		"quit":           "q",
+		"enter":          "enter",
+		"cancel":         "esc",
+		"back":           "backspace",
+		"switch":         "tab",
+		"help":           "ctrl+h",
+		"copy":           "ctrl+c",
+		"copy_overwrite": "ctrl+r",
+		"move":           "ctrl+x",
+		"move_overwrite": "ctrl+t",
+		"delete":         "ctrl+d",
+		"trash":          "delete",
+		"mkdir":          "ctrl+f",
+		"mkfile":         "ctrl+n",
+		"editor":         "ctrl+k",
+		"open_with":      "ctrl+o",
+		"select":         "space",
+		"preview":        "ctrl+p",
+		"preview_focus":  "alt+p",
+		"sort":           "ctrl+s",
+		"theme":          "alt+t",
+		"columns":        "alt+c",
+		"format":         "alt+f",
+		"size_format":    "alt+z",
+		"dir_size":       "alt+s",
+		"disk_usage":     "alt+u",
+		"duplicates":     "alt+d",
+		"compare":        "alt+=",
+		"sync":           "alt+y",
+		"diff":           "alt+v",
+		"pack":           "alt+a",
+		"extract":        "alt+x",
+		"connect":        "alt+r",
+		"new_tab":        "alt+n",
+		"close_tab":      "alt+w",
+		"next_tab":       "alt+.",
+		"prev_tab":       "alt+,",
+		"filter":         "alt+l",
+		"history_back":   "alt+left",
+		"history_next":   "alt+right",
+		"history":        "alt+h",
+		"bookmarks":      "alt+b",
+		"jump":           "alt+j",
+	}
+}
@@ -0,0 +1,319 @@
+package diff
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"unicode"
+)
+
+type Op int
+
+const (
+	Equal Op = iota
+	Delete
+	Insert
+	// Change is a line replaced by another one, only used by the rows
+	Change
+)
+
+// Edit is a step of the script turning a into b: the element A of a is
+// kept or deleted, the element B of b is kept or inserted.
+type Edit struct {
+	Op Op
+	A  int
+	B  int
+}
+
+// maxEditCost bounds the work of the Myers algorithm, beyond it the
+// remaining elements are reported as replaced.
+const maxEditCost = 2000
+
+// Compute returns the shortest edit script between a and b with the Myers
+// algorithm, after stripping the common prefix and suffix.
+func Compute[T comparable](a []T, b []T) []Edit {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	edits := []Edit{}
+	for i := 0; i < prefix; i++ {
+		edits = append(edits, Edit{Op: Equal, A: i, B: i})
+	}
+	for _, e := range myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]) {
+		e.A += prefix
+		e.B += prefix
+		edits = append(edits, e)
+	}
+	for i := suffix; i > 0; i-- {
+		edits = append(edits, Edit{Op: Equal, A: len(a) - i, B: len(b) - i})
+	}
+	return edits
+}
+
+func myers[T comparable](a []T, b []T) []Edit {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return replace(0, n, 0, m)
+	}
+
+	offset := n + m
+	v := make([]int, 2*offset+2)
+	trace := [][]int{}
+
+	found := false
+	for d := 0; d <= n+m && d <= maxEditCost; d++ {
+		trace = append(trace, append([]int{}, v[offset-d:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+		if found {
+			break
+		}
+	}
+
+	if !found {
+		return replace(0, n, 0, m)
+	}
+
+	// Walk the trace back from the end, the snapshot of step d holds the
+	// diagonals -d..d+1 before the step
+	edits := []Edit{}
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d]
+		at := func(k int) int { return prev[k+d] }
+
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			edits = append(edits, Edit{Op: Equal, A: x, B: y})
+		}
+		if x == prevX {
+			y--
+			edits = append(edits, Edit{Op: Insert, A: x, B: y})
+		} else {
+			x--
+			edits = append(edits, Edit{Op: Delete, A: x, B: y})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		edits = append(edits, Edit{Op: Equal, A: x, B: y})
+	}
+
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
+
+func replace(a0 int, a1 int, b0 int, b1 int) []Edit {
+	edits := []Edit{}
+	for i := a0; i < a1; i++ {
+		edits = append(edits, Edit{Op: Delete, A: i, B: b0})
+	}
+	for i := b0; i < b1; i++ {
+		edits = append(edits, Edit{Op: Insert, A: a1, B: i})
+	}
+	return edits
+}
+
+const (
+	WhitespaceNone   = "none"
+	WhitespaceChange = "change"
+	WhitespaceAll    = "all"
+)
+
+var WhitespaceModes = []string{WhitespaceNone, WhitespaceChange, WhitespaceAll}
+
+// normalize returns the key used to compare a line: with WhitespaceChange
+// the runs of spaces count as one and the trailing ones are ignored, with
+// WhitespaceAll the spaces are ignored altogether.
+func normalize(line string, whitespace string) string {
+	switch whitespace {
+	case WhitespaceChange:
+		return strings.Join(strings.Fields(line), " ")
+	case WhitespaceAll:
+		return strings.Map(func(r rune) rune {
+			if unicode.IsSpace(r) {
+				return -1
+			}
+			return r
+		}, line)
+	}
+	return line
+}
+
+// Row is a line of the side-by-side view, Left and Right are the indexes
+// of the lines or -1 when the side is empty.
+type Row struct {
+	Op    Op
+	Left  int
+	Right int
+}
+
+// Rows compares the lines and pairs the deleted and inserted lines of each
+// change, the unpaired ones are left alone on their side.
+func Rows(a []string, b []string, whitespace string) []Row {
+	ka := make([]string, len(a))
+	for i, line := range a {
+		ka[i] = normalize(line, whitespace)
+	}
+	kb := make([]string, len(b))
+	for i, line := range b {
+		kb[i] = normalize(line, whitespace)
+	}
+
+	rows := []Row{}
+	dels, ins := []int{}, []int{}
+	flush := func() {
+		for i := 0; i < len(dels) || i < len(ins); i++ {
+			row := Row{Op: Delete, Left: -1, Right: -1}
+			if i < len(dels) {
+				row.Left = dels[i]
+			}
+			if i < len(ins) {
+				row.Right = ins[i]
+				row.Op = Insert
+			}
+			if row.Left >= 0 && row.Right >= 0 {
+				row.Op = Change
+			}
+			rows = append(rows, row)
+		}
+		dels, ins = dels[:0], ins[:0]
+	}
+
+	for _, e := range Compute(ka, kb) {
+		switch e.Op {
+		case Equal:
+			flush()
+			rows = append(rows, Row{Op: Equal, Left: e.A, Right: e.B})
+		case Delete:
+			dels = append(dels, e.A)
+		case Insert:
+			ins = append(ins, e.B)
+		}
+	}
+	flush()
+	return rows
+}
+
+// Hunks returns the indexes of the first row of each group of changes.
+func Hunks(rows []Row) []int {
+	hunks := []int{}
+	for i, row := range rows {
+		if row.Op != Equal && (i == 0 || rows[i-1].Op == Equal) {
+			hunks = append(hunks, i)
+		}
+	}
+	return hunks
+}
+
+// Span is a range of runes of a line, Changed when it differs from the
+// other line.
+type Span struct {
+	Start   int
+	End     int
+	Changed bool
+}
+
+// Inline compares two lines rune by rune and returns the spans of each one.
+func Inline(a string, b string) ([]Span, []Span) {
+	ra, rb := []rune(a), []rune(b)
+	sa, sb := []Span{}, []Span{}
+
+	add := func(spans []Span, i int, changed bool) []Span {
+		if n := len(spans); n > 0 && spans[n-1].Changed == changed && spans[n-1].End == i {
+			spans[n-1].End = i + 1
+			return spans
+		}
+		return append(spans, Span{Start: i, End: i + 1, Changed: changed})
+	}
+
+	for _, e := range Compute(ra, rb) {
+		switch e.Op {
+		case Equal:
+			sa = add(sa, e.A, false)
+			sb = add(sb, e.B, false)
+		case Delete:
+			sa = add(sa, e.A, true)
+		case Insert:
+			sb = add(sb, e.B, true)
+		}
+	}
+	return sa, sb
+}
+
+// IsBinary reports whether the data looks binary, like git does: a NUL
+// byte in the first 8000 bytes.
+func IsBinary(data []byte) bool {
+	return bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0
+}
+
+// FirstDifference returns the offset of the first byte that differs
+// between the readers, or -1 if their contents are equal.
+func FirstDifference(a io.Reader, b io.Reader) (int64, error) {
+	ra, rb := bufio.NewReader(a), bufio.NewReader(b)
+	var offset int64
+	for {
+		ca, errA := ra.ReadByte()
+		cb, errB := rb.ReadByte()
+		if errA != nil && errA != io.EOF {
+			return 0, errA
+		}
+		if errB != nil && errB != io.EOF {
+			return 0, errB
+		}
+		if errA == io.EOF && errB == io.EOF {
+			return -1, nil
+		}
+		if errA == io.EOF || errB == io.EOF || ca != cb {
+			return offset, nil
+		}
+		offset++
+	}
+}
+
+// SplitLines splits the text in lines without the line terminators.
+func SplitLines(text string) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return []string{}
+	}
+	return strings.Split(text, "\n")
+}
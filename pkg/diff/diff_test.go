@@ -0,0 +1,215 @@
+package diff
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// check verifies that the script turns a into b, and returns its number of
+// deletions and insertions.
+func check[T comparable](t *testing.T, a []T, b []T, edits []Edit) int {
+	t.Helper()
+	got := []T{}
+	ia, ib, cost := 0, 0, 0
+	for _, e := range edits {
+		switch e.Op {
+		case Equal:
+			if e.A != ia || e.B != ib || a[e.A] != b[e.B] {
+				t.Fatalf("invalid equal edit %+v at %v,%v", e, ia, ib)
+			}
+			got = append(got, a[e.A])
+			ia++
+			ib++
+		case Delete:
+			if e.A != ia {
+				t.Fatalf("invalid delete edit %+v at %v", e, ia)
+			}
+			ia++
+			cost++
+		case Insert:
+			if e.B != ib {
+				t.Fatalf("invalid insert edit %+v at %v", e, ib)
+			}
+			got = append(got, b[e.B])
+			ib++
+			cost++
+		}
+	}
+	if ia != len(a) || len(got) != len(b) || len(b) > 0 && !reflect.DeepEqual(got, b) {
+		t.Fatalf("script turns %v into %v, want %v", a, got, b)
+	}
+	return cost
+}
+
+func TestCompute(t *testing.T) {
+	tests := []struct {
+		a, b string
+		cost int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"abc", "", 3},
+		{"abc", "abc", 0},
+		{"abcXdef", "abcYdef", 2},
+		{"abc", "abcdef", 3},
+		{"def", "abcdef", 3},
+		{"ABCABBA", "CBABAC", 5},
+		{"kitten", "sitting", 5},
+	}
+	for _, tt := range tests {
+		a, b := []rune(tt.a), []rune(tt.b)
+		if cost := check(t, a, b, Compute(a, b)); cost != tt.cost {
+			t.Errorf("Compute(%q, %q) costs %v, want %v", tt.a, tt.b, cost, tt.cost)
+		}
+	}
+}
+
+func TestComputeCostCap(t *testing.T) {
+	// No element in common, the cost is beyond maxEditCost
+	n := maxEditCost/2 + 100
+	a, b := make([]int, n), make([]int, n)
+	for i := range a {
+		a[i] = i
+		b[i] = n + i
+	}
+	// With a common prefix and suffix, kept out of the replacement
+	a = append(append([]int{-1}, a...), -2)
+	b = append(append([]int{-1}, b...), -2)
+
+	edits := Compute(a, b)
+	if cost := check(t, a, b, edits); cost != 2*n {
+		t.Errorf("cost %v, want %v", cost, 2*n)
+	}
+	if edits[0].Op != Equal || edits[len(edits)-1].Op != Equal {
+		t.Errorf("the common prefix and suffix are not kept")
+	}
+	for i, e := range edits[1 : len(edits)-1] {
+		want := Delete
+		if i >= n {
+			want = Insert
+		}
+		if e.Op != want {
+			t.Fatalf("edit %v = %+v, want the deletions then the insertions", i, e)
+		}
+	}
+}
+
+func TestRows(t *testing.T) {
+	tests := []struct {
+		name       string
+		a, b       []string
+		whitespace string
+		want       []Row
+	}{
+		{
+			name: "empty left",
+			b:    []string{"a", "b"},
+			want: []Row{{Insert, -1, 0}, {Insert, -1, 1}},
+		},
+		{
+			name: "empty right",
+			a:    []string{"a"},
+			want: []Row{{Delete, 0, -1}},
+		},
+		{
+			name: "change and deletion",
+			a:    []string{"a", "b", "c", "d"},
+			b:    []string{"a", "x", "d"},
+			want: []Row{{Equal, 0, 0}, {Change, 1, 1}, {Delete, 2, -1}, {Equal, 3, 2}},
+		},
+		{
+			name: "whitespace kept",
+			a:    []string{"a  b ", "c d"},
+			b:    []string{"a b", "cd"},
+			want: []Row{{Change, 0, 0}, {Change, 1, 1}},
+		},
+		{
+			name:       "whitespace change",
+			a:          []string{"a  b ", "c d"},
+			b:          []string{"a b", "cd"},
+			whitespace: WhitespaceChange,
+			want:       []Row{{Equal, 0, 0}, {Change, 1, 1}},
+		},
+		{
+			name:       "whitespace ignored",
+			a:          []string{"a  b ", "c d"},
+			b:          []string{"a b", "cd"},
+			whitespace: WhitespaceAll,
+			want:       []Row{{Equal, 0, 0}, {Equal, 1, 1}},
+		},
+	}
+	for _, tt := range tests {
+		whitespace := tt.whitespace
+		if whitespace == "" {
+			whitespace = WhitespaceNone
+		}
+		if got := Rows(tt.a, tt.b, whitespace); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v: Rows = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHunks(t *testing.T) {
+	rows := []Row{{Equal, 0, 0}, {Change, 1, 1}, {Delete, 2, -1}, {Equal, 3, 2}, {Insert, -1, 3}}
+	if got, want := Hunks(rows), []int{1, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Hunks = %v, want %v", got, want)
+	}
+}
+
+func TestInline(t *testing.T) {
+	a, b := Inline("hello world", "hello there")
+	if len(a) == 0 || a[0] != (Span{0, 6, false}) || len(b) == 0 || b[0] != (Span{0, 6, false}) {
+		t.Errorf("Inline spans %v %v, want the common prefix first", a, b)
+	}
+	for _, spans := range [][]Span{a, b} {
+		end := 0
+		for _, s := range spans {
+			if s.Start != end {
+				t.Fatalf("spans %v are not contiguous", spans)
+			}
+			end = s.End
+		}
+		if end != len("hello world") {
+			t.Errorf("spans %v end at %v", spans, end)
+		}
+	}
+}
+
+func TestFirstDifference(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int64
+	}{
+		{"", "", -1},
+		{"abc", "abc", -1},
+		{"abc", "abd", 2},
+		{"abc", "abcd", 3},
+		{"abcd", "abc", 3},
+		{"", "a", 0},
+	}
+	for _, tt := range tests {
+		got, err := FirstDifference(strings.NewReader(tt.a), strings.NewReader(tt.b))
+		if err != nil || got != tt.want {
+			t.Errorf("FirstDifference(%q, %q) = %v %v, want %v", tt.a, tt.b, got, err, tt.want)
+		}
+	}
+}
+
+func TestSplitLines(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", []string{}},
+		{"a", []string{"a"}},
+		{"a\n", []string{"a"}},
+		{"a\r\nb\r\n", []string{"a", "b"}},
+		{"a\r\n\nb", []string{"a", "", "b"}},
+	}
+	for _, tt := range tests {
+		if got := SplitLines(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitLines(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package dirsize
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Result is the size of a directory computed by the Calculator.
+type Result struct {
+	Group   string
+	Path    string
+	ModTime time.Time
+	Size    uint64
+	Err     error
+}
+
+type entry struct {
+	modTime time.Time
+	size    uint64
+}
+
+type job struct {
+	ctx   context.Context
+	group string
+	path  string
+}
+
+type group struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	pending map[string]bool
+}
+
+// Calculator computes the recursive size of directories in a pool of
+// workers. The requests are grouped (e.g. by panel) so that the pending
+// ones can be cancelled together, and the results are cached by path and
+// modification time.
+type Calculator struct {
+	mu      sync.Mutex
+	cache   map[string]entry
+	groups  map[string]*group
+	jobs    chan job
+	results chan Result
+}
+
+func New(workers int) *Calculator {
+	c := &Calculator{
+		cache:   map[string]entry{},
+		groups:  map[string]*group{},
+		jobs:    make(chan job),
+		results: make(chan Result, 64),
+	}
+	for i := 0; i < max(1, workers); i++ {
+		go c.worker()
+	}
+	return c
+}
+
+// Results returns the channel where the computed sizes are sent.
+func (c *Calculator) Results() <-chan Result {
+	return c.results
+}
+
+// Cached returns the size computed for the directory, if it has not been
+// modified since.
+func (c *Calculator) Cached(path string, modTime time.Time) (uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.cache[path]
+	if !ok || !e.modTime.Equal(modTime) {
+		return 0, false
+	}
+	return e.size, true
+}
+
+// Compute queues the directories not already cached or pending.
+func (c *Calculator) Compute(name string, paths []string) {
+	c.mu.Lock()
+	g := c.group(name)
+	todo := []string{}
+	for _, path := range paths {
+		if g.pending[path] {
+			continue
+		}
+		if info, err := os.Stat(path); err == nil {
+			if e, ok := c.cache[path]; ok && e.modTime.Equal(info.ModTime()) {
+				continue
+			}
+		}
+		g.pending[path] = true
+		todo = append(todo, path)
+	}
+	ctx := g.ctx
+	c.mu.Unlock()
+
+	go func() {
+		for _, path := range todo {
+			select {
+			case c.jobs <- job{ctx: ctx, group: name, path: path}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Cancel drops the pending requests of the group and stops the running ones.
+func (c *Calculator) Cancel(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if g, ok := c.groups[name]; ok {
+		g.cancel()
+		delete(c.groups, name)
+	}
+}
+
+// Pending returns the number of directories of the group still to compute.
+func (c *Calculator) Pending(name string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if g, ok := c.groups[name]; ok {
+		return len(g.pending)
+	}
+	return 0
+}
+
+// Invalidate removes the cached size of path and of its parents, whose
+// modification time does not change when a nested file does.
+func (c *Calculator) Invalidate(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for {
+		delete(c.cache, path)
+		parent := filepath.Dir(path)
+		if parent == path {
+			return
+		}
+		path = parent
+	}
+}
+
+func (c *Calculator) group(name string) *group {
+	g, ok := c.groups[name]
+	if !ok {
+		ctx, cancel := context.WithCancel(context.Background())
+		g = &group{ctx: ctx, cancel: cancel, pending: map[string]bool{}}
+		c.groups[name] = g
+	}
+	return g
+}
+
+func (c *Calculator) worker() {
+	for j := range c.jobs {
+		if j.ctx.Err() != nil {
+			continue
+		}
+
+		res := Result{Group: j.group, Path: j.path}
+		info, err := os.Stat(j.path)
+		if err == nil {
+			res.ModTime = info.ModTime()
+			res.Size, err = Size(j.ctx, j.path)
+		}
+		res.Err = err
+
+		c.mu.Lock()
+		g, ok := c.groups[j.group]
+		current := ok && g.ctx == j.ctx
+		if current {
+			delete(g.pending, j.path)
+		}
+		if err == nil {
+			c.cache[j.path] = entry{modTime: res.ModTime, size: res.Size}
+		}
+		c.mu.Unlock()
+
+		if j.ctx.Err() != nil {
+			continue
+		}
+		c.results <- res
+	}
+}
+
+// Size returns the total size of the regular files under path, without
+// following symbolic links.
+func Size(ctx context.Context, path string) (uint64, error) {
+	var size uint64
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			// Unreadable entries are skipped
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += uint64(info.Size())
+			}
+		}
+		return nil
+	})
+	return size, err
+}
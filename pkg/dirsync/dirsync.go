@@ -0,0 +1,283 @@
+package dirsync
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/sandrolain/gommander/pkg/dirsize"
+	gfs "github.com/sandrolain/gommander/pkg/fs"
+)
+
+const (
+	// ModeMirror makes the target identical to the source, deleting the
+	// files missing from the source
+	ModeMirror = "mirror"
+	// ModeUpdate copies the new files and the newer versions to the target
+	ModeUpdate = "update"
+	// ModeTwoWay copies the new files and the newer versions in both
+	// directions
+	ModeTwoWay = "two-way"
+)
+
+var Modes = []string{ModeMirror, ModeUpdate, ModeTwoWay}
+
+type Action string
+
+const (
+	ActionCopy      Action = "copy"
+	ActionOverwrite Action = "overwrite"
+	ActionDelete    Action = "delete"
+)
+
+// Item is a step of the plan. From is empty for the deletions. Conflicts
+// are the files changed on both sides or of a different type, they are
+// planned from the newer side but disabled by default.
+type Item struct {
+	Action   Action
+	Rel      string
+	From     string
+	To       string
+	Dir      bool
+	Size     uint64
+	Reverse  bool
+	Conflict bool
+	Enabled  bool
+}
+
+type entry struct {
+	path string
+	dir  bool
+	info fs.FileInfo
+}
+
+// Plan compares the trees under src and dst and returns the steps to
+// synchronize them. In the two-way mode the steps going from dst to src are
+// marked as Reverse. Symbolic links and special files are ignored.
+func Plan(ctx context.Context, src string, dst string, mode string) ([]Item, error) {
+	p := &planner{ctx: ctx, mode: mode, src: src, dst: dst}
+	if err := p.compare(""); err != nil {
+		return nil, err
+	}
+	return p.items, nil
+}
+
+type planner struct {
+	ctx   context.Context
+	mode  string
+	src   string
+	dst   string
+	items []Item
+}
+
+func readEntries(dir string) (map[string]entry, error) {
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := map[string]entry{}
+	for _, e := range dirEntries {
+		if !e.IsDir() && !e.Type().IsRegular() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		entries[e.Name()] = entry{path: filepath.Join(dir, e.Name()), dir: e.IsDir(), info: info}
+	}
+	return entries, nil
+}
+
+func (p *planner) compare(rel string) error {
+	if err := p.ctx.Err(); err != nil {
+		return err
+	}
+
+	srcEntries, err := readEntries(filepath.Join(p.src, rel))
+	if err != nil {
+		return err
+	}
+	dstEntries, err := readEntries(filepath.Join(p.dst, rel))
+	if err != nil {
+		return err
+	}
+
+	names := []string{}
+	for name := range srcEntries {
+		names = append(names, name)
+	}
+	for name := range dstEntries {
+		if _, ok := srcEntries[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		itemRel := filepath.Join(rel, name)
+		s, inSrc := srcEntries[name]
+		d, inDst := dstEntries[name]
+
+		switch {
+		case !inDst:
+			p.add(ActionCopy, itemRel, s, filepath.Join(p.dst, itemRel), false, false)
+		case !inSrc:
+			switch p.mode {
+			case ModeMirror:
+				p.add(ActionDelete, itemRel, d, d.path, false, false)
+			case ModeTwoWay:
+				p.add(ActionCopy, itemRel, d, filepath.Join(p.src, itemRel), true, false)
+			}
+		case s.dir && d.dir:
+			if err := p.compare(itemRel); err != nil {
+				return err
+			}
+		default:
+			p.compareFiles(itemRel, s, d)
+		}
+	}
+	return nil
+}
+
+func (p *planner) compareFiles(rel string, s entry, d entry) {
+	// Compare to the second, as some file systems do not store fractions
+	st := s.info.ModTime().Truncate(time.Second)
+	dt := d.info.ModTime().Truncate(time.Second)
+	sameType := s.dir == d.dir
+
+	if sameType && s.info.Size() == d.info.Size() && st.Equal(dt) {
+		return
+	}
+
+	switch p.mode {
+	case ModeMirror:
+		p.add(ActionOverwrite, rel, s, d.path, false, !sameType)
+	case ModeUpdate:
+		if st.After(dt) {
+			p.add(ActionOverwrite, rel, s, d.path, false, !sameType)
+		}
+	case ModeTwoWay:
+		switch {
+		case st.After(dt):
+			p.add(ActionOverwrite, rel, s, d.path, false, !sameType)
+		case dt.After(st):
+			p.add(ActionOverwrite, rel, d, s.path, true, !sameType)
+		default:
+			p.add(ActionOverwrite, rel, s, d.path, false, true)
+		}
+	}
+}
+
+func (p *planner) add(action Action, rel string, e entry, to string, reverse bool, conflict bool) {
+	item := Item{
+		Action:   action,
+		Rel:      rel,
+		To:       to,
+		Dir:      e.dir,
+		Size:     uint64(e.info.Size()),
+		Reverse:  reverse,
+		Conflict: conflict,
+		Enabled:  !conflict,
+	}
+	if action != ActionDelete {
+		item.From = e.path
+	}
+	if e.dir {
+		item.Size, _ = dirsize.Size(p.ctx, e.path)
+	}
+	p.items = append(p.items, item)
+}
+
+// Progress reports the execution of a plan, Rel being the item in progress.
+type Progress struct {
+	Items      int
+	Total      int
+	Bytes      uint64
+	TotalBytes uint64
+	Rel        string
+}
+
+// Run executes the enabled items of the plan in order, stopping at the first
+// error or when the context is canceled, and returns the number of items
+// executed.
+func Run(ctx context.Context, items []Item, progress func(Progress)) (int, error) {
+	p := Progress{}
+	for _, it := range items {
+		if it.Enabled {
+			p.Total++
+			if it.Action != ActionDelete {
+				p.TotalBytes += it.Size
+			}
+		}
+	}
+
+	for _, it := range items {
+		if !it.Enabled {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return p.Items, err
+		}
+		p.Rel = it.Rel
+		if progress != nil {
+			progress(p)
+		}
+		if err := it.Execute(); err != nil {
+			return p.Items, fmt.Errorf("%s: %v", it.Rel, err)
+		}
+		p.Items++
+		if it.Action != ActionDelete {
+			p.Bytes += it.Size
+		}
+	}
+	p.Rel = ""
+	if progress != nil {
+		progress(p)
+	}
+	return p.Items, nil
+}
+
+// Execute applies the step with the copy engine, keeping the modification
+// times of the copied files so that they are not planned again.
+func (it Item) Execute() error {
+	if it.Action == ActionDelete {
+		return gfs.DeleteTrees([]string{it.To})
+	}
+
+	if it.Action == ActionOverwrite {
+		// A file replacing a directory or the other way round
+		if info, err := os.Lstat(it.To); err == nil && info.IsDir() != it.Dir {
+			if err := gfs.DeleteTrees([]string{it.To}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := gfs.CopyFile(it.From, filepath.Dir(it.To), true); err != nil {
+		return err
+	}
+	return copyTimes(it.From, it.To)
+}
+
+func copyTimes(from string, to string) error {
+	return filepath.WalkDir(from, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(from, path)
+		if err != nil {
+			return err
+		}
+		return os.Chtimes(filepath.Join(to, rel), info.ModTime(), info.ModTime())
+	})
+}
@@ -0,0 +1,234 @@
+package dirsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var base = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func writeFile(t *testing.T, p string, content string, modTime time.Time) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(p, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, p string) string {
+	t.Helper()
+	data, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// describe lists the items as "action rel", with "<" for the reverse ones
+// and "!" for the conflicts.
+func describe(items []Item) []string {
+	res := []string{}
+	for _, it := range items {
+		s := fmt.Sprintf("%s %s", it.Action, filepath.ToSlash(it.Rel))
+		if it.Reverse {
+			s += " <"
+		}
+		if it.Conflict {
+			s += " !"
+		}
+		res = append(res, s)
+	}
+	return res
+}
+
+func plan(t *testing.T, src string, dst string, mode string, want []string) []Item {
+	t.Helper()
+	items, err := Plan(context.Background(), src, dst, mode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := describe(items); !reflect.DeepEqual(got, want) {
+		t.Fatalf("%v plan = %q, want %q", mode, got, want)
+	}
+	return items
+}
+
+func run(t *testing.T, items []Item) {
+	t.Helper()
+	if _, err := Run(context.Background(), items, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMirror(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	writeFile(t, filepath.Join(src, "new.txt"), "new", base)
+	writeFile(t, filepath.Join(src, "same.txt"), "same", base)
+	writeFile(t, filepath.Join(dst, "same.txt"), "same", base)
+	writeFile(t, filepath.Join(src, "changed.txt"), "changed", base)
+	writeFile(t, filepath.Join(dst, "changed.txt"), "old", base.Add(time.Hour))
+	writeFile(t, filepath.Join(src, "dir", "a.txt"), "a", base)
+	writeFile(t, filepath.Join(dst, "extra.txt"), "extra", base)
+	writeFile(t, filepath.Join(dst, "extradir", "b.txt"), "b", base)
+
+	items := plan(t, src, dst, ModeMirror, []string{
+		"overwrite changed.txt",
+		"copy dir",
+		"delete extra.txt",
+		"delete extradir",
+		"copy new.txt",
+	})
+	run(t, items)
+
+	plan(t, src, dst, ModeMirror, []string{})
+	if got := readFile(t, filepath.Join(dst, "changed.txt")); got != "changed" {
+		t.Errorf("changed.txt = %q, want the source version", got)
+	}
+	for _, name := range []string{"extra.txt", "extradir"} {
+		if _, err := os.Lstat(filepath.Join(dst, name)); err == nil {
+			t.Errorf("%v not deleted", name)
+		}
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	writeFile(t, filepath.Join(src, "newer.txt"), "newer", base.Add(time.Hour))
+	writeFile(t, filepath.Join(dst, "newer.txt"), "old", base)
+	writeFile(t, filepath.Join(src, "older.txt"), "old", base)
+	writeFile(t, filepath.Join(dst, "older.txt"), "newer", base.Add(time.Hour))
+	writeFile(t, filepath.Join(dst, "extra.txt"), "extra", base)
+
+	items := plan(t, src, dst, ModeUpdate, []string{"overwrite newer.txt"})
+	run(t, items)
+
+	if got := readFile(t, filepath.Join(dst, "newer.txt")); got != "newer" {
+		t.Errorf("newer.txt = %q, want the source version", got)
+	}
+	if got := readFile(t, filepath.Join(dst, "older.txt")); got != "newer" {
+		t.Errorf("older.txt = %q, want the target version kept", got)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "extra.txt")); err != nil {
+		t.Errorf("extra.txt deleted in update mode: %v", err)
+	}
+}
+
+func TestTwoWay(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	writeFile(t, filepath.Join(src, "a.txt"), "src", base.Add(time.Hour))
+	writeFile(t, filepath.Join(dst, "a.txt"), "dst", base)
+	writeFile(t, filepath.Join(src, "b.txt"), "src", base)
+	writeFile(t, filepath.Join(dst, "b.txt"), "dst", base.Add(time.Hour))
+	writeFile(t, filepath.Join(src, "only-src.txt"), "src", base)
+	writeFile(t, filepath.Join(dst, "only-dst.txt"), "dst", base)
+	// Changed on both sides in the same second
+	writeFile(t, filepath.Join(src, "both.txt"), "source version", base)
+	writeFile(t, filepath.Join(dst, "both.txt"), "target", base.Add(500*time.Millisecond))
+
+	items := plan(t, src, dst, ModeTwoWay, []string{
+		"overwrite a.txt",
+		"overwrite b.txt <",
+		"overwrite both.txt !",
+		"copy only-dst.txt <",
+		"copy only-src.txt",
+	})
+	for _, it := range items {
+		if it.Enabled == it.Conflict {
+			t.Errorf("%v: enabled %v with conflict %v", it.Rel, it.Enabled, it.Conflict)
+		}
+	}
+	run(t, items)
+
+	plan(t, src, dst, ModeTwoWay, []string{"overwrite both.txt !"})
+	if got := readFile(t, filepath.Join(dst, "a.txt")); got != "src" {
+		t.Errorf("a.txt = %q, want the newer source version", got)
+	}
+	if got := readFile(t, filepath.Join(src, "b.txt")); got != "dst" {
+		t.Errorf("b.txt = %q, want the newer target version", got)
+	}
+	if got := readFile(t, filepath.Join(src, "only-dst.txt")); got != "dst" {
+		t.Errorf("only-dst.txt = %q, want it copied back", got)
+	}
+}
+
+func TestSameSecond(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	// File systems storing only seconds truncate the copied times
+	writeFile(t, filepath.Join(src, "a.txt"), "a", base.Add(700*time.Millisecond))
+	writeFile(t, filepath.Join(dst, "a.txt"), "a", base)
+
+	for _, mode := range Modes {
+		plan(t, src, dst, mode, []string{})
+	}
+}
+
+func TestTypeConflict(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	writeFile(t, filepath.Join(src, "x"), "file", base.Add(time.Hour))
+	writeFile(t, filepath.Join(dst, "x", "inner.txt"), "inner", base)
+
+	items := plan(t, src, dst, ModeMirror, []string{"overwrite x !"})
+	if items[0].Enabled {
+		t.Errorf("type conflict enabled by default")
+	}
+	items[0].Enabled = true
+	run(t, items)
+
+	if got := readFile(t, filepath.Join(dst, "x")); got != "file" {
+		t.Errorf("x = %q, want the file replacing the directory", got)
+	}
+	plan(t, src, dst, ModeMirror, []string{})
+}
+
+func TestRunCanceled(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	writeFile(t, filepath.Join(src, "a.txt"), "a", base)
+	items := plan(t, src, dst, ModeMirror, []string{"copy a.txt"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	done, err := Run(ctx, items, nil)
+	if done != 0 || !errors.Is(err, context.Canceled) {
+		t.Errorf("Run canceled = %v %v, want 0 and %v", done, err, context.Canceled)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "a.txt")); err == nil {
+		t.Errorf("a.txt copied after the cancellation")
+	}
+}
+
+func TestRunProgress(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	writeFile(t, filepath.Join(src, "a.txt"), "aa", base)
+	writeFile(t, filepath.Join(src, "b.txt"), "bbb", base)
+	items := plan(t, src, dst, ModeMirror, []string{"copy a.txt", "copy b.txt"})
+	items[0].Enabled = false
+
+	reports := []Progress{}
+	done, err := Run(context.Background(), items, func(p Progress) {
+		reports = append(reports, p)
+	})
+	if err != nil || done != 1 {
+		t.Fatalf("Run = %v %v, want 1 item", done, err)
+	}
+	want := []Progress{
+		{Items: 0, Total: 1, Bytes: 0, TotalBytes: 3, Rel: "b.txt"},
+		{Items: 1, Total: 1, Bytes: 3, TotalBytes: 3},
+	}
+	if !reflect.DeepEqual(reports, want) {
+		t.Errorf("progress = %+v, want %+v", reports, want)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "a.txt")); err == nil {
+		t.Errorf("disabled a.txt copied")
+	}
+}
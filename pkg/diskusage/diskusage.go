@@ -0,0 +1,150 @@
+package diskusage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Node is a file or a directory of a scanned tree. The size and the item
+// count of a directory include all its descendants.
+type Node struct {
+	Name     string
+	Path     string
+	Dir      bool
+	Size     uint64
+	Items    int
+	Err      error
+	Parent   *Node
+	Children []*Node
+}
+
+// Progress reports the number of files and bytes scanned so far.
+type Progress struct {
+	Files int
+	Bytes uint64
+}
+
+// progressInterval throttles the progress reports
+const progressInterval = 100 * time.Millisecond
+
+// Scan walks the tree under root, without following symbolic links, and
+// returns it with the children sorted by size. The progress function, if
+// not nil, is called periodically from the scanning goroutine.
+func Scan(ctx context.Context, root string, progress func(Progress)) (*Node, error) {
+	info, err := os.Lstat(root)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &scanner{ctx: ctx, progress: progress, last: time.Now()}
+	n := &Node{Name: filepath.Base(root), Path: root, Dir: info.IsDir()}
+	if !n.Dir {
+		n.Size = uint64(info.Size())
+		return n, nil
+	}
+
+	if err := s.scanDir(n); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+type scanner struct {
+	ctx      context.Context
+	progress func(Progress)
+	current  Progress
+	last     time.Time
+}
+
+func (s *scanner) scanDir(n *Node) error {
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(n.Path)
+	if err != nil {
+		// Unreadable directories are kept with the error
+		n.Err = err
+	}
+
+	for _, entry := range entries {
+		child := &Node{
+			Name:   entry.Name(),
+			Path:   filepath.Join(n.Path, entry.Name()),
+			Dir:    entry.IsDir(),
+			Parent: n,
+		}
+
+		if child.Dir {
+			if err := s.scanDir(child); err != nil {
+				return err
+			}
+		} else {
+			if info, err := entry.Info(); err == nil {
+				child.Size = uint64(info.Size())
+			}
+			s.current.Files++
+			s.current.Bytes += child.Size
+			s.report()
+		}
+
+		n.Children = append(n.Children, child)
+		n.Size += child.Size
+		n.Items += child.Items + 1
+	}
+
+	n.Sort()
+	return nil
+}
+
+func (s *scanner) report() {
+	if s.progress == nil || time.Since(s.last) < progressInterval {
+		return
+	}
+	s.last = time.Now()
+	s.progress(s.current)
+}
+
+// Sort orders the children by size, largest first.
+func (n *Node) Sort() {
+	sort.SliceStable(n.Children, func(i, j int) bool {
+		a, b := n.Children[i], n.Children[j]
+		if a.Size != b.Size {
+			return a.Size > b.Size
+		}
+		return a.Name < b.Name
+	})
+}
+
+// Remove detaches the node from the tree, updating the totals of its
+// ancestors.
+func (n *Node) Remove() {
+	parent := n.Parent
+	if parent == nil {
+		return
+	}
+
+	for i, child := range parent.Children {
+		if child == n {
+			parent.Children = append(parent.Children[:i], parent.Children[i+1:]...)
+			break
+		}
+	}
+
+	for p := parent; p != nil; p = p.Parent {
+		p.Size -= n.Size
+		p.Items -= n.Items + 1
+	}
+	n.Parent = nil
+}
+
+// Percent returns the share of the node in the size of its parent.
+func (n *Node) Percent() float64 {
+	if n.Parent == nil || n.Parent.Size == 0 {
+		return 100
+	}
+	return float64(n.Size) * 100 / float64(n.Parent.Size)
+}
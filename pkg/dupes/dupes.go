@@ -0,0 +1,254 @@
+package dupes
+
+import (
+	"context"
+	"crypto/sha256"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// partialSize is the number of bytes hashed from the start of each file to
+// discard most of the candidates before the full hash.
+const partialSize = 4096
+
+// progressInterval throttles the progress reports
+const progressInterval = 100 * time.Millisecond
+
+const (
+	PhaseScan    = "Scanning"
+	PhasePartial = "Hashing (partial)"
+	PhaseFull    = "Hashing (full)"
+)
+
+// Group is a set of files with the same content.
+type Group struct {
+	Size  uint64
+	Files []string
+}
+
+// Reclaimable is the space freed by removing all the copies but one.
+func (g Group) Reclaimable() uint64 {
+	if len(g.Files) < 2 {
+		return 0
+	}
+	return g.Size * uint64(len(g.Files)-1)
+}
+
+// Progress reports the current phase and, while hashing, how many of the
+// candidate files have been processed.
+type Progress struct {
+	Phase string
+	Files int
+	Done  int
+	Total int
+}
+
+type file struct {
+	path string
+	info fs.FileInfo
+}
+
+// Find walks the tree under root, without following symbolic links, and
+// returns the groups of regular non-empty files with the same content,
+// largest reclaimable space first. Hard links to the same file are counted
+// once. The progress function, if not nil, is called periodically from the
+// calling goroutine.
+func Find(ctx context.Context, root string, progress func(Progress)) ([]Group, error) {
+	r := &reporter{progress: progress, last: time.Now()}
+	r.current.Phase = PhaseScan
+
+	bySize := map[int64][]file{}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err != nil {
+			// Unreadable entries are skipped
+			if d != nil && d.IsDir() && path != root {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil || info.Size() == 0 {
+			return nil
+		}
+		bySize[info.Size()] = append(bySize[info.Size()], file{path: path, info: info})
+		r.current.Files++
+		r.report(false)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := [][]file{}
+	for _, files := range bySize {
+		files = uniqueFiles(files)
+		if len(files) > 1 {
+			candidates = append(candidates, files)
+			r.current.Total += len(files)
+		}
+	}
+
+	r.current.Phase = PhasePartial
+	r.report(true)
+	partial := [][]file{}
+	for _, files := range candidates {
+		groups, err := groupByHash(ctx, files, partialSize, r)
+		if err != nil {
+			return nil, err
+		}
+		partial = append(partial, groups...)
+	}
+
+	r.current.Phase = PhaseFull
+	r.current.Done = 0
+	r.current.Total = 0
+	for _, files := range partial {
+		r.current.Total += len(files)
+	}
+	r.report(true)
+
+	res := []Group{}
+	for _, files := range partial {
+		// Files smaller than the partial hash are already compared in full
+		if files[0].info.Size() <= partialSize {
+			res = append(res, newGroup(files))
+			r.current.Done += len(files)
+			continue
+		}
+
+		groups, err := groupByHash(ctx, files, -1, r)
+		if err != nil {
+			return nil, err
+		}
+		for _, g := range groups {
+			res = append(res, newGroup(g))
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		a, b := res[i].Reclaimable(), res[j].Reclaimable()
+		if a != b {
+			return a > b
+		}
+		return res[i].Files[0] < res[j].Files[0]
+	})
+	return res, nil
+}
+
+func newGroup(files []file) Group {
+	g := Group{Size: uint64(files[0].info.Size())}
+	for _, f := range files {
+		g.Files = append(g.Files, f.path)
+	}
+	sort.Strings(g.Files)
+	return g
+}
+
+// uniqueFiles drops the hard links to files already in the list.
+func uniqueFiles(files []file) []file {
+	res := []file{}
+	for _, f := range files {
+		same := false
+		for _, u := range res {
+			if os.SameFile(f.info, u.info) {
+				same = true
+				break
+			}
+		}
+		if !same {
+			res = append(res, f)
+		}
+	}
+	return res
+}
+
+// groupByHash splits the files by the hash of their first n bytes, or of
+// the whole content if n is negative, keeping the groups of two or more.
+// Unreadable files are left out.
+func groupByHash(ctx context.Context, files []file, n int64, r *reporter) ([][]file, error) {
+	byHash := map[[sha256.Size]byte][]file{}
+	order := [][sha256.Size]byte{}
+	for _, f := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		sum, err := hashFile(ctx, f.path, n)
+		r.current.Done++
+		r.report(false)
+		if err != nil {
+			continue
+		}
+		if _, ok := byHash[sum]; !ok {
+			order = append(order, sum)
+		}
+		byHash[sum] = append(byHash[sum], f)
+	}
+
+	res := [][]file{}
+	for _, sum := range order {
+		if len(byHash[sum]) > 1 {
+			res = append(res, byHash[sum])
+		}
+	}
+	return res, nil
+}
+
+func hashFile(ctx context.Context, path string, n int64) ([sha256.Size]byte, error) {
+	var sum [sha256.Size]byte
+
+	f, err := os.Open(path)
+	if err != nil {
+		return sum, err
+	}
+	defer f.Close()
+
+	var src io.Reader = &ctxReader{ctx: ctx, r: f}
+	if n >= 0 {
+		src = io.LimitReader(src, n)
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, src); err != nil {
+		return sum, err
+	}
+	copy(sum[:], h.Sum(nil))
+	return sum, nil
+}
+
+// ctxReader stops reading large files as soon as the context is done.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+type reporter struct {
+	progress func(Progress)
+	current  Progress
+	last     time.Time
+}
+
+func (r *reporter) report(force bool) {
+	if r.progress == nil || (!force && time.Since(r.last) < progressInterval) {
+		return
+	}
+	r.last = time.Now()
+	r.progress(r.current)
+}
@@ -0,0 +1,198 @@
+package frecency
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sandrolain/gommander/pkg/state"
+)
+
+const stateFile = "frecency.json"
+
+// maxRank is the total rank above which the ranks are aged, dropping the
+// directories not visited for long.
+const maxRank = 10000
+
+type Entry struct {
+	Path string    `json:"path"`
+	Rank float64   `json:"rank"`
+	Last time.Time `json:"last"`
+}
+
+// Score weights the rank of the entry by the time since the last visit, like
+// zoxide.
+func (e Entry) Score(now time.Time) float64 {
+	age := now.Sub(e.Last)
+	switch {
+	case age < time.Hour:
+		return e.Rank * 4
+	case age < 24*time.Hour:
+		return e.Rank * 2
+	case age < 7*24*time.Hour:
+		return e.Rank / 2
+	default:
+		return e.Rank / 4
+	}
+}
+
+// DB holds the directories visited, by path.
+type DB struct {
+	Entries map[string]*Entry `json:"entries"`
+}
+
+func New() *DB {
+	return &DB{Entries: map[string]*Entry{}}
+}
+
+func Load() (*DB, error) {
+	db := New()
+	if err := state.Load(stateFile, db); err != nil {
+		return New(), err
+	}
+	if db.Entries == nil {
+		db.Entries = map[string]*Entry{}
+	}
+	return db, nil
+}
+
+func (db *DB) Save() error {
+	return state.Save(stateFile, db)
+}
+
+// Visit records a visit of the directory.
+func (db *DB) Visit(path string, now time.Time) {
+	db.add(path, 1, now)
+	db.age()
+}
+
+func (db *DB) add(path string, rank float64, now time.Time) {
+	e, ok := db.Entries[path]
+	if !ok {
+		e = &Entry{Path: path}
+		db.Entries[path] = e
+	}
+	e.Rank += rank
+	if now.After(e.Last) {
+		e.Last = now
+	}
+}
+
+// age scales the ranks down when their total exceeds maxRank, removing the
+// entries falling below 1.
+func (db *DB) age() {
+	total := 0.0
+	for _, e := range db.Entries {
+		total += e.Rank
+	}
+	if total <= maxRank {
+		return
+	}
+
+	factor := 0.9 * maxRank / total
+	for path, e := range db.Entries {
+		e.Rank *= factor
+		if e.Rank < 1 {
+			delete(db.Entries, path)
+		}
+	}
+}
+
+func (db *DB) Remove(path string) {
+	delete(db.Entries, path)
+}
+
+// Query returns the entries matching the query, see Match, the ones whose
+// terms all appear as is first, then by score.
+func (db *DB) Query(query string, now time.Time) []Entry {
+	terms := strings.Fields(strings.ToLower(query))
+
+	type match struct {
+		entry Entry
+		exact bool
+		score float64
+	}
+	matches := []match{}
+	for _, e := range db.Entries {
+		ok, exact := Match(e.Path, terms)
+		if ok {
+			matches = append(matches, match{entry: *e, exact: exact, score: e.Score(now)})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].exact != matches[j].exact {
+			return matches[i].exact
+		}
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].entry.Path < matches[j].entry.Path
+	})
+
+	entries := make([]Entry, len(matches))
+	for i, m := range matches {
+		entries[i] = m.entry
+	}
+	return entries
+}
+
+// Match reports whether the lowercase terms match the segments of the path
+// in order, the last term matching the last segment. A term matches a
+// segment containing it, or containing its letters in order; exact is set
+// when all the terms are contained as is.
+func Match(path string, terms []string) (ok bool, exact bool) {
+	if len(terms) == 0 {
+		return true, true
+	}
+
+	segments := strings.FieldsFunc(strings.ToLower(path), func(r rune) bool {
+		return r == '/' || r == '\\'
+	})
+	if len(segments) == 0 {
+		return false, false
+	}
+
+	// The last term is matched against the last segment, the others in
+	// order against the segments before
+	last := len(terms) - 1
+	ok, lastExact := matchSegment(segments[len(segments)-1], terms[last])
+	if !ok {
+		return false, false
+	}
+	exact = lastExact
+
+	s := 0
+	for _, term := range terms[:last] {
+		found := false
+		for ; s < len(segments)-1; s++ {
+			if ok, termExact := matchSegment(segments[s], term); ok {
+				exact = exact && termExact
+				found = true
+				s++
+				break
+			}
+		}
+		if !found {
+			return false, false
+		}
+	}
+	return true, exact
+}
+
+func matchSegment(segment string, term string) (ok bool, exact bool) {
+	if strings.Contains(segment, term) {
+		return true, true
+	}
+
+	// The letters of the term in order, like "gmd" in "gommander"
+	rest := segment
+	for _, r := range term {
+		i := strings.IndexRune(rest, r)
+		if i < 0 {
+			return false, false
+		}
+		rest = rest[i+len(string(r)):]
+	}
+	return true, false
+}
@@ -0,0 +1,86 @@
+package frecency
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoDatabase is returned importing from a tool that is not installed.
+var ErrNoDatabase = errors.New("database not found")
+
+// ImportZoxide adds the directories of the zoxide database with their score,
+// listed by the zoxide command. It returns the number of directories read.
+func (db *DB) ImportZoxide(now time.Time) (int, error) {
+	out, err := exec.Command("zoxide", "query", "--list", "--score").Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		return 0, ErrNoDatabase
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error running zoxide: %v", err)
+	}
+
+	// Lines like "  12.5 /home/user/dir"
+	return db.importLines(bytes.NewReader(out), now, func(line string) (string, string, bool) {
+		return strings.Cut(strings.TrimSpace(line), " ")
+	})
+}
+
+// ImportAutojump adds the directories of the autojump database with their
+// weight. It returns the number of directories read.
+func (db *DB) ImportAutojump(now time.Time) (int, error) {
+	f, err := os.Open(autojumpFile())
+	if os.IsNotExist(err) {
+		return 0, ErrNoDatabase
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	// Lines like "12.5\t/home/user/dir"
+	return db.importLines(f, now, func(line string) (string, string, bool) {
+		return strings.Cut(line, "\t")
+	})
+}
+
+func autojumpFile() string {
+	home, _ := os.UserHomeDir()
+	if runtime.GOOS == "darwin" {
+		return filepath.Join(home, "Library", "autojump", "autojump.txt")
+	}
+	data := os.Getenv("XDG_DATA_HOME")
+	if data == "" {
+		data = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(data, "autojump", "autojump.txt")
+}
+
+func (db *DB) importLines(r io.Reader, now time.Time, split func(line string) (string, string, bool)) (int, error) {
+	count := 0
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		score, path, ok := split(scanner.Text())
+		if !ok {
+			continue
+		}
+		rank, err := strconv.ParseFloat(strings.TrimSpace(score), 64)
+		path = strings.TrimSpace(path)
+		if err != nil || rank <= 0 || !filepath.IsAbs(path) {
+			continue
+		}
+		db.add(path, rank, now)
+		count++
+	}
+	db.age()
+	return count, scanner.Err()
+}
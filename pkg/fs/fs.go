@@ -96,6 +96,17 @@ func DeleteFiles(filePaths []string) error {
 	return nil
 }
 
+// DeleteTrees removes the files and the directories with their contents.
+func DeleteTrees(paths []string) error {
+	for _, path := range paths {
+		err := os.RemoveAll(path)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func TrashFile(filePath string) error {
 	_, err := gotrash.MoveToTrash(filePath)
 	if err != nil {
@@ -148,3 +159,23 @@ func MoveFile(srcFilePath, dstDirPath string, permitOverwrite bool) error {
 
 	return nil
 }
+
+// ReplaceWithHardLink replaces the target file with a hard link to the
+// source file. The link is created next to the target and renamed over it,
+// so the target is left untouched if the link cannot be created.
+func ReplaceWithHardLink(target, source string) error {
+	tmp := filepath.Join(filepath.Dir(target), fmt.Sprintf(".%s.link%d", filepath.Base(target), os.Getpid()))
+
+	err := os.Link(source, tmp)
+	if err != nil {
+		return fmt.Errorf("error linking %v to %v: %v", target, source, err)
+	}
+
+	err = os.Rename(tmp, target)
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("error replacing file %v: %v", target, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,274 @@
+package icons
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	StyleNerd  = "nerd"
+	StyleASCII = "ascii"
+)
+
+var Styles = []string{StyleNerd, StyleASCII}
+
+// Type keys used for the files not matched by name or extension
+const (
+	TypeDir    = "dir"
+	TypeFile   = "file"
+	TypeParent = "parent"
+	TypeLink   = "link"
+	TypeExec   = "exec"
+	TypePipe   = "pipe"
+	TypeSocket = "socket"
+	TypeDevice = "device"
+)
+
+var TypeKeys = []string{TypeDir, TypeFile, TypeParent, TypeLink, TypeExec, TypePipe, TypeSocket, TypeDevice}
+
+// Set maps the file types, the well-known names and the extensions to
+// their icons. Names take precedence over the extensions and the
+// extensions over the executable type.
+type Set struct {
+	Types      map[string]string
+	Names      map[string]string
+	Extensions map[string]string
+}
+
+// Nerd Font glyphs, see https://www.nerdfonts.com/cheat-sheet
+const (
+	nfFolder   = ""
+	nfFolderUp = ""
+	nfGitDir   = ""
+	nfFile     = ""
+	nfLink     = ""
+	nfTerminal = ""
+	nfPipe     = ""
+	nfSocket   = ""
+	nfDevice   = ""
+	nfGit      = ""
+	nfGo       = ""
+	nfMake     = ""
+	nfDocker   = ""
+	nfMarkdown = ""
+	nfJSON     = ""
+	nfYAML     = ""
+	nfTOML     = ""
+	nfConfig   = ""
+	nfLicense  = ""
+	nfLock     = ""
+	nfImage    = ""
+	nfAudio    = ""
+	nfVideo    = ""
+	nfArchive  = ""
+	nfPDF      = ""
+	nfDoc      = ""
+	nfSheet    = ""
+	nfSlides   = ""
+	nfText     = ""
+)
+
+var nerdExtensions = map[string]string{
+	"go":   nfGo,
+	"js":   "",
+	"mjs":  "",
+	"ts":   "",
+	"tsx":  "",
+	"jsx":  "",
+	"py":   "",
+	"rs":   "",
+	"c":    "",
+	"h":    "",
+	"cpp":  "",
+	"hpp":  "",
+	"java": "",
+	"rb":   "",
+	"php":  "",
+	"lua":  "",
+	"vim":  "",
+	"html": "",
+	"css":  "",
+	"scss": "",
+	"sql":  "",
+	"sh":   nfTerminal,
+	"bash": nfTerminal,
+	"zsh":  nfTerminal,
+	"fish": nfTerminal,
+	"md":   nfMarkdown,
+	"json": nfJSON,
+	"yaml": nfYAML,
+	"yml":  nfYAML,
+	"toml": nfTOML,
+	"ini":  nfConfig,
+	"conf": nfConfig,
+	"lock": nfLock,
+	"txt":  nfText,
+	"log":  nfText,
+	"png":  nfImage,
+	"jpg":  nfImage,
+	"jpeg": nfImage,
+	"gif":  nfImage,
+	"webp": nfImage,
+	"svg":  nfImage,
+	"ico":  nfImage,
+	"mp3":  nfAudio,
+	"flac": nfAudio,
+	"ogg":  nfAudio,
+	"wav":  nfAudio,
+	"mp4":  nfVideo,
+	"mkv":  nfVideo,
+	"webm": nfVideo,
+	"avi":  nfVideo,
+	"mov":  nfVideo,
+	"zip":  nfArchive,
+	"tar":  nfArchive,
+	"gz":   nfArchive,
+	"tgz":  nfArchive,
+	"bz2":  nfArchive,
+	"xz":   nfArchive,
+	"zst":  nfArchive,
+	"7z":   nfArchive,
+	"rar":  nfArchive,
+	"pdf":  nfPDF,
+	"doc":  nfDoc,
+	"docx": nfDoc,
+	"odt":  nfDoc,
+	"xls":  nfSheet,
+	"xlsx": nfSheet,
+	"ods":  nfSheet,
+	"csv":  nfSheet,
+	"ppt":  nfSlides,
+	"pptx": nfSlides,
+	"odp":  nfSlides,
+}
+
+var nerdNames = map[string]string{
+	".git":               nfGitDir,
+	".gitignore":         nfGit,
+	".gitattributes":     nfGit,
+	".gitmodules":        nfGit,
+	"go.mod":             nfGo,
+	"go.sum":             nfGo,
+	"go.work":            nfGo,
+	"Makefile":           nfMake,
+	"makefile":           nfMake,
+	"GNUmakefile":        nfMake,
+	"Dockerfile":         nfDocker,
+	"docker-compose.yml": nfDocker,
+	"compose.yaml":       nfDocker,
+	".dockerignore":      nfDocker,
+	"LICENSE":            nfLicense,
+	"LICENSE.md":         nfLicense,
+	"COPYING":            nfLicense,
+}
+
+func Nerd() Set {
+	return Set{
+		Types: map[string]string{
+			TypeDir:    nfFolder,
+			TypeFile:   nfFile,
+			TypeParent: nfFolderUp,
+			TypeLink:   nfLink,
+			TypeExec:   nfTerminal,
+			TypePipe:   nfPipe,
+			TypeSocket: nfSocket,
+			TypeDevice: nfDevice,
+		},
+		Names:      copyMap(nerdNames),
+		Extensions: copyMap(nerdExtensions),
+	}
+}
+
+// ASCII uses the type indicators of `ls -F`.
+func ASCII() Set {
+	return Set{
+		Types: map[string]string{
+			TypeDir:    "/",
+			TypeFile:   " ",
+			TypeParent: "^",
+			TypeLink:   "@",
+			TypeExec:   "*",
+			TypePipe:   "|",
+			TypeSocket: "=",
+			TypeDevice: "#",
+		},
+		Names:      map[string]string{},
+		Extensions: map[string]string{},
+	}
+}
+
+func New(style string) Set {
+	if style == StyleASCII {
+		return ASCII()
+	}
+	return Nerd()
+}
+
+func ValidStyle(style string) bool {
+	return style == StyleNerd || style == StyleASCII
+}
+
+func ValidType(key string) bool {
+	for _, t := range TypeKeys {
+		if t == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Override replaces the icons of the set, the extensions can be given with
+// or without the leading dot.
+func (s Set) Override(types map[string]string, names map[string]string, exts map[string]string) {
+	for k, v := range types {
+		s.Types[k] = v
+	}
+	for k, v := range names {
+		s.Names[k] = v
+	}
+	for k, v := range exts {
+		s.Extensions[strings.ToLower(strings.TrimPrefix(k, "."))] = v
+	}
+}
+
+func (s Set) Icon(name string, mode fs.FileMode) string {
+	if name == ".." {
+		return s.Types[TypeParent]
+	}
+
+	if icon, ok := s.Names[name]; ok {
+		return icon
+	}
+
+	switch {
+	case mode&fs.ModeSymlink != 0:
+		return s.Types[TypeLink]
+	case mode.IsDir():
+		return s.Types[TypeDir]
+	case mode&fs.ModeNamedPipe != 0:
+		return s.Types[TypePipe]
+	case mode&fs.ModeSocket != 0:
+		return s.Types[TypeSocket]
+	case mode&fs.ModeDevice != 0:
+		return s.Types[TypeDevice]
+	}
+
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(name), "."))
+	if icon, ok := s.Extensions[ext]; ok && ext != "" {
+		return icon
+	}
+
+	if mode&0o111 != 0 {
+		return s.Types[TypeExec]
+	}
+	return s.Types[TypeFile]
+}
+
+func copyMap(m map[string]string) map[string]string {
+	res := make(map[string]string, len(m))
+	for k, v := range m {
+		res[k] = v
+	}
+	return res
+}
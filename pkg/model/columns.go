@@ -0,0 +1,264 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/evertras/bubble-table/table"
+	"github.com/sandrolain/gommander/pkg/config"
+	"github.com/sandrolain/gommander/pkg/rows"
+	"github.com/sandrolain/gommander/pkg/state"
+)
+
+func tableColumns(columns []config.Column) []table.Column {
+	res := []table.Column{}
+	for _, col := range columns {
+		def, ok := rows.FindColumn(col.Key)
+		if !ok {
+			continue
+		}
+
+		width := def.Width
+		if col.Width > 0 {
+			width = col.Width
+		}
+
+		var c table.Column
+		if def.Flex {
+			c = table.NewFlexColumn(def.Key, def.Title, width)
+		} else {
+			c = table.NewColumn(def.Key, def.Title, width)
+		}
+		res = append(res, c.WithStyle(lipgloss.NewStyle().Align(def.Align)))
+	}
+	return res
+}
+
+// tableKey converts a key name of the configuration to the string used by
+// the table key map.
+func tableKey(key string) string {
+	if key == "space" {
+		return " "
+	}
+	return key
+}
+
+const layoutStateFile = "layout.json"
+
+// layout holds the columns of each panel, restored on restart.
+type layout struct {
+	Left  []config.Column `json:"left"`
+	Right []config.Column `json:"right"`
+}
+
+type columnItem struct {
+	key     string
+	width   int
+	visible bool
+}
+
+func hasColumnKey(columns []config.Column, key string) bool {
+	for _, col := range columns {
+		if col.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func validLayout(columns []config.Column) bool {
+	hasName := false
+	for _, col := range columns {
+		if _, ok := rows.FindColumn(col.Key); !ok || col.Width < 0 {
+			return false
+		}
+		hasName = hasName || col.Key == "name"
+	}
+	return hasName
+}
+
+func loadLayout(cfg config.Config) (layout, error) {
+	l := layout{Left: cfg.VisibleColumns(), Right: cfg.VisibleColumns()}
+
+	var saved layout
+	if err := state.Load(layoutStateFile, &saved); err != nil {
+		return l, err
+	}
+	if validLayout(saved.Left) {
+		l.Left = saved.Left
+	}
+	if validLayout(saved.Right) {
+		l.Right = saved.Right
+	}
+	return l, nil
+}
+
+// columnKeys returns the keys of the columns shown in any panel.
+func columnKeys(l layout) []string {
+	keys := []string{}
+	seen := map[string]bool{}
+	for _, col := range append(append([]config.Column{}, l.Left...), l.Right...) {
+		if !seen[col.Key] {
+			seen[col.Key] = true
+			keys = append(keys, col.Key)
+		}
+	}
+	return keys
+}
+
+// panelRowsOptions returns the options with the data needed by the columns
+// of both panels.
+func panelRowsOptions(cfg config.Config, opts rows.Options, l layout) rows.Options {
+	opts.Columns = columnKeys(l)
+	opts.Icons = nil
+	if slices.Contains(opts.Columns, "icon") {
+		set := cfg.IconSet()
+		opts.Icons = &set
+	}
+	return opts
+}
+
+func (m *model) panelColumns(panel string) []config.Column {
+	if panel == "left" {
+		return m.layout.Left
+	}
+	return m.layout.Right
+}
+
+func (m *model) setPanelColumns(panel string, columns []config.Column) {
+	if panel == "left" {
+		m.layout.Left = columns
+		m.leftTable = m.leftTable.WithColumns(tableColumns(columns))
+	} else {
+		m.layout.Right = columns
+		m.rightTable = m.rightTable.WithColumns(tableColumns(columns))
+	}
+
+	m.rowsOptions = panelRowsOptions(m.config, m.rowsOptions, m.layout)
+
+	m.refreshLeftTableRows()
+	m.refreshRightTableRows()
+	m.autoDirSizes("left")
+	m.autoDirSizes("right")
+
+	if err := state.Save(layoutStateFile, m.layout); err != nil {
+		m.showError(fmt.Sprintf("Error saving the layout: %v", err))
+	}
+}
+
+func (m *model) columnsDialog() {
+	m.columnsPanel = m.active
+	m.columnsCursor = 0
+	m.columnsItems = columnItems(m.panelColumns(m.active))
+}
+
+// columnItems lists the visible columns first, in their order, followed by
+// the hidden ones.
+func columnItems(columns []config.Column) []columnItem {
+	items := []columnItem{}
+	shown := map[string]bool{}
+	for _, col := range columns {
+		items = append(items, columnItem{key: col.Key, width: col.Width, visible: true})
+		shown[col.Key] = true
+	}
+	for _, def := range rows.ColumnDefs {
+		if !shown[def.Key] {
+			items = append(items, columnItem{key: def.Key})
+		}
+	}
+	return items
+}
+
+func (m *model) applyColumnItems() {
+	columns := []config.Column{}
+	for _, item := range m.columnsItems {
+		if item.visible {
+			columns = append(columns, config.Column{Key: item.key, Width: item.width})
+		}
+	}
+	m.setPanelColumns(m.columnsPanel, columns)
+}
+
+func (m *model) updateColumnsDialog(key string) {
+	items := m.columnsItems
+	i := m.columnsCursor
+	def, _ := rows.FindColumn(items[i].key)
+	width := items[i].width
+	if width == 0 {
+		width = def.Width
+	}
+
+	switch key {
+	case "up", "k":
+		m.columnsCursor = max(0, i-1)
+		return
+	case "down", "j":
+		m.columnsCursor = min(len(items)-1, i+1)
+		return
+	case KeyCancel, "q":
+		m.columnsPanel = ""
+		return
+	case KeySelect, KeyEnter:
+		// The name column is always shown
+		if items[i].key != "name" {
+			items[i].visible = !items[i].visible
+		}
+	case "+", "right", "l":
+		items[i].width = width + 1
+	case "-", "left", "h":
+		items[i].width = max(1, width-1)
+	case "shift+up", "K":
+		if i > 0 {
+			items[i-1], items[i] = items[i], items[i-1]
+			m.columnsCursor--
+		}
+	case "shift+down", "J":
+		if i < len(items)-1 {
+			items[i+1], items[i] = items[i], items[i+1]
+			m.columnsCursor++
+		}
+	case "r":
+		m.columnsItems = columnItems(m.config.VisibleColumns())
+	default:
+		return
+	}
+
+	m.applyColumnItems()
+}
+
+func (m *model) renderColumnsDialog() string {
+	lines := []string{}
+	for i, item := range m.columnsItems {
+		def, _ := rows.FindColumn(item.key)
+		check := "[ ]"
+		if item.visible {
+			check = "[x]"
+		}
+		title := def.Title
+		if title == "" {
+			title = strings.ToUpper(def.Key[:1]) + def.Key[1:]
+		}
+		width := item.width
+		if width == 0 {
+			width = def.Width
+		}
+		line := fmt.Sprintf("%s %-12s %3d", check, title, width)
+		if def.Flex {
+			line += " (flex)"
+		}
+		if i == m.columnsCursor {
+			line = activeButtonStyle.Padding(0, 1).MarginLeft(0).Render(line)
+		} else {
+			line = lipgloss.NewStyle().Padding(0, 1).Render(line)
+		}
+		lines = append(lines, line)
+	}
+
+	title := lipgloss.NewStyle().Bold(true).MarginBottom(1).Render(fmt.Sprintf("Columns (%s panel)", m.columnsPanel))
+	help := lipgloss.NewStyle().Faint(true).MarginTop(1).Render("space: show/hide  +/-: width  K/J: move  r: reset  esc: close")
+	ui := lipgloss.JoinVertical(lipgloss.Left, title, strings.Join(lines, "\n"), help)
+
+	return m.renderOverlayViews(dialogBoxStyle.Render(ui))
+}
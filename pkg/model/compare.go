@@ -0,0 +1,191 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/evertras/bubble-table/table"
+	"github.com/sandrolain/gommander/pkg/compare"
+	"github.com/sandrolain/gommander/pkg/config"
+)
+
+// Markers of the compare column, as seen from the panel of the row
+var compareMarkers = map[compare.Status]string{
+	compare.Identical: "=",
+	compare.Different: "≠",
+}
+
+type compareMsg struct {
+	seq      int
+	leftDir  string
+	rightDir string
+	statuses map[string]compare.Status
+	err      error
+}
+
+func (m *model) compareDialog() {
+	items := []string{"By size and date", "By content", "Clear comparison"}
+	m.menuDialog("Compare panels", items, func(i int, m *model) error {
+		switch i {
+		case 0:
+			m.pendingCmd = m.comparePanels(compare.BySizeTime)
+		case 1:
+			if !localDir(m.leftPanelDir) || !localDir(m.rightPanelDir) {
+				return fmt.Errorf("Comparing by content is only available on the local file system")
+			}
+			m.pendingCmd = m.comparePanels(compare.ByContent)
+		default:
+			m.clearComparison()
+		}
+		return nil
+	})
+}
+
+func compareEntries(t table.Model) map[string]compare.Entry {
+	entries := map[string]compare.Entry{}
+	for _, row := range t.GetVisibleRows() {
+		name, _ := row.Data["name"].(string)
+		if name == ".." {
+			continue
+		}
+		path, _ := row.Data["path"].(string)
+		size, _ := row.Data["usize"].(uint64)
+		modTime, _ := row.Data["umodified"].(time.Time)
+		entries[name] = compare.Entry{
+			Path:    path,
+			Dir:     row.Data["dir"] == true,
+			Size:    size,
+			ModTime: modTime,
+		}
+	}
+	return entries
+}
+
+// comparePanels compares the listed files of the two panels in the
+// background, hashing the files when comparing by content.
+func (m *model) comparePanels(mode string) tea.Cmd {
+	m.cancelComparison()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.compareCancel = cancel
+	m.compareSeq++
+
+	seq := m.compareSeq
+	leftDir, rightDir := m.leftPanelDir, m.rightPanelDir
+	left, right := compareEntries(m.leftTable), compareEntries(m.rightTable)
+	return func() tea.Msg {
+		statuses, err := compare.Dirs(ctx, left, right, mode)
+		return compareMsg{seq: seq, leftDir: leftDir, rightDir: rightDir, statuses: statuses, err: err}
+	}
+}
+
+func (m *model) cancelComparison() {
+	if m.compareCancel != nil {
+		m.compareCancel()
+		m.compareCancel = nil
+	}
+}
+
+func (m *model) updateCompare(msg compareMsg) {
+	if msg.seq != m.compareSeq || errors.Is(msg.err, context.Canceled) {
+		return
+	}
+	m.compareCancel = nil
+	if msg.err != nil {
+		m.showError(fmt.Sprintf("Error comparing files: %v", msg.err))
+		return
+	}
+	// The panels changed directory while comparing
+	if msg.leftDir != m.leftPanelDir || msg.rightDir != m.rightPanelDir {
+		return
+	}
+
+	m.leftTable = markComparison(m.leftTable, msg.statuses, compare.OnlyLeft, compare.LeftNewer, compare.RightNewer)
+	m.rightTable = markComparison(m.rightTable, msg.statuses, compare.OnlyRight, compare.RightNewer, compare.LeftNewer)
+	m.leftTable = m.leftTable.WithColumns(m.compareColumns("left"))
+	m.rightTable = m.rightTable.WithColumns(m.compareColumns("right"))
+}
+
+// markComparison sets the compare marker of the rows and selects the files
+// that are missing on the other side, newer or different.
+func markComparison(t table.Model, statuses map[string]compare.Status, only compare.Status, newer compare.Status, older compare.Status) table.Model {
+	highlighted := t.GetHighlightedRowIndex()
+
+	res := []table.Row{}
+	for _, row := range t.GetVisibleRows() {
+		name, _ := row.Data["name"].(string)
+		status, ok := statuses[name]
+		if !ok || name == ".." {
+			delete(row.Data, "compare")
+			res = append(res, row.Selected(false))
+			continue
+		}
+
+		marker := compareMarkers[status]
+		switch status {
+		case only:
+			marker = "+"
+		case newer:
+			marker = ">"
+		case older:
+			marker = "<"
+		}
+		row.Data["compare"] = marker
+		res = append(res, row.Selected(status == only || status == newer || status == compare.Different))
+	}
+
+	return t.WithRows(res).WithHighlightedRow(highlighted)
+}
+
+func (m *model) clearComparison() {
+	m.cancelComparison()
+	for _, t := range []table.Model{m.leftTable, m.rightTable} {
+		for _, row := range t.GetVisibleRows() {
+			delete(row.Data, "compare")
+		}
+	}
+	m.leftTable = m.leftTable.WithColumns(m.compareColumns("left"))
+	m.rightTable = m.rightTable.WithColumns(m.compareColumns("right"))
+}
+
+func compared(t table.Model) bool {
+	for _, row := range t.GetVisibleRows() {
+		if _, ok := row.Data["compare"]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// compareColumns returns the columns of the panel, with the compare column
+// added in front while the rows show a comparison.
+func (m *model) compareColumns(panel string) []table.Column {
+	columns := m.panelColumns(panel)
+	t := m.leftTable
+	if panel == "right" {
+		t = m.rightTable
+	}
+	if compared(t) && !hasColumnKey(columns, "compare") {
+		columns = append([]config.Column{{Key: "compare"}}, columns...)
+	}
+	return tableColumns(columns)
+}
+
+func (m *model) compareStatus(t table.Model) string {
+	if m.compareCancel != nil {
+		return " | Comparing..."
+	}
+	if !compared(t) {
+		return ""
+	}
+	n := 0
+	for _, row := range t.GetVisibleRows() {
+		if marker, ok := row.Data["compare"]; ok && marker != "=" && marker != "" {
+			n++
+		}
+	}
+	return fmt.Sprintf(" | Differences: %d", n)
+}
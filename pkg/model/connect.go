@@ -0,0 +1,130 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sandrolain/gommander/pkg/vfs"
+)
+
+// connection is a connection to a remote host in progress, the directory is
+// shown in the panel once connected.
+type connection struct {
+	panel string
+	host  string
+}
+
+type connectedMsg struct {
+	conn *connection
+	path string
+	err  error
+}
+
+// remotePath returns the path of the panels of an address, either a URL
+// like s3://bucket/prefix or an SFTP address like [user@]host[:port][/path],
+// the host being possibly an alias of the ssh config.
+func remotePath(address string) string {
+	if strings.Contains(address, "://") {
+		return address
+	}
+	return "sftp://" + address
+}
+
+func connect(conn *connection, path string) tea.Cmd {
+	return func() tea.Msg {
+		fsys, _, err := vfs.Resolve(path)
+		if err != nil {
+			return connectedMsg{conn: conn, err: err}
+		}
+
+		// Without a path start in the directory of the server, usually the home
+		if !strings.Contains(path[strings.Index(path, "://")+3:], "/") {
+			path += "/"
+			if client, ok := fsys.(*vfs.SFTP); ok {
+				if wd, err := client.Getwd(); err == nil {
+					path = vfs.Join(path, wd)
+				}
+			}
+		}
+		return connectedMsg{conn: conn, path: path}
+	}
+}
+
+// connectDialog asks for the remote file system to show in the active panel,
+// among the configured ones or by address.
+func (m *model) connectDialog() {
+	names := []string{}
+	for name := range m.config.Remotes {
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		m.addressDialog()
+		return
+	}
+	sort.Strings(names)
+
+	items := []string{}
+	for _, name := range names {
+		items = append(items, fmt.Sprintf("%s (%s)", name, m.config.Remotes[name].Type))
+	}
+	items = append(items, "Enter an address...")
+	m.menuDialog("Connect to", items, func(i int, m *model) error {
+		if i == len(names) {
+			m.addressDialog()
+			return nil
+		}
+		m.startConnection(names[i], m.config.Remotes[names[i]].Type+"://"+names[i])
+		return nil
+	})
+}
+
+func (m *model) addressDialog() {
+	m.inputDialog("Enter the address to connect to ([user@]host[:port][/path] for SFTP, webdav://host/path or s3://bucket/prefix):", func(value string, m *model) error {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return fmt.Errorf("Address cannot be empty")
+		}
+		path := remotePath(value)
+		host := path[strings.Index(path, "://")+3:]
+		m.startConnection(strings.SplitN(host, "/", 2)[0], path)
+		return nil
+	})
+}
+
+func (m *model) startConnection(host string, path string) {
+	conn := &connection{panel: m.active, host: host}
+	m.connection = conn
+	m.pendingCmd = connect(conn, path)
+}
+
+func (m *model) updateConnected(msg connectedMsg) {
+	if msg.conn != m.connection {
+		return
+	}
+	m.connection = nil
+	if msg.err != nil {
+		m.showError(fmt.Sprintf("Error connecting to %s: %v", msg.conn.host, msg.err))
+		return
+	}
+
+	// Show the directory in the panel the connection was started from
+	active := m.active
+	m.active = msg.conn.panel
+	defer func() { m.active = active }()
+	if _, err := m.enterFile(msg.path); err != nil {
+		m.showError(fmt.Sprintf("Error opening %s: %v", msg.path, err))
+	}
+}
+
+func (m *model) cancelConnection() {
+	m.connection = nil
+}
+
+func (m *model) connectionStatus(panel string) string {
+	if m.connection == nil || m.connection.panel != panel {
+		return ""
+	}
+	return fmt.Sprintf(" | Connecting to %s...", m.connection.host)
+}
@@ -3,54 +3,114 @@ package model
 const (
 	extraRows         = 7
 	headFootExtraRows = 2 // Rows for header and footer bars
+	tabBarRows        = 1 // Row for the tab bar above the tables
 )
 
-const (
-	ColWhite       = "#FFFFFF"
-	ColDarkGray    = "#333333"
-	ColViolet      = "#874BFD"
-	ColLightBlue   = "#87afff"
-	ColPink        = "#F25D94"
-	ColLightYellow = "#FFF7DB"
-	ColDarkYellow  = "#888B7E"
-	ColYellow      = "#ffd703"
-	ColOrange      = "#ffaf00"
+// Key bindings, set from the configuration by applyKeys
+var (
+	KeyQuit       string
+	KeyEnter      string
+	KeyCancel     string
+	KeyBack       string
+	KeySwitch     string
+	KeyHelp       string
+	KeyCopy       string
+	KeyCopyO      string
+	KeyMove       string
+	KeyMoveO      string
+	KeyDelete     string
+	KeyTrash      string
+	KeyMkdir      string
+	KeyMkfile     string
+	KeyEditor     string
+	KeyOpenWith   string
+	KeySelect     string
+	KeyPreview    string
+	KeyPFocus     string
+	KeySort       string
+	KeyTheme      string
+	KeyColumns    string
+	KeyFormat     string
+	KeySizeFormat string
+	KeyDirSize    string
+	KeyDiskUsage  string
+	KeyDuplicates string
+	KeyCompare    string
+	KeySync       string
+	KeyDiff       string
+	KeyPack       string
+	KeyExtract    string
+	KeyConnect    string
+	KeyNewTab     string
+	KeyCloseTab   string
+	KeyNextTab    string
+	KeyPrevTab    string
+	KeyFilter     string
+	KeyHistBack   string
+	KeyHistNext   string
+	KeyHistory    string
+	KeyBookmarks  string
+	KeyJump       string
 )
 
-const (
-	KeyQuit   = "q"
-	KeyEnter  = "enter"
-	KeyCancel = "esc"
-	KeyBack   = "backspace"
-	KeySwitch = "tab"
-	KeyHelp   = "ctrl+h"
-	KeyCopy   = "ctrl+c"
-	KeyCopyO  = "ctrl+r"
-	KeyMove   = "ctrl+x"
-	KeyMoveO  = "ctrl+t"
-	KeyDelete = "ctrl+d"
-	KeyTrash  = "delete"
-	KeyMkdir  = "ctrl+f"
-	KeyMkfile = "ctrl+n"
-	KeyVscode = "ctrl+k"
-	KeySelect = "space"
-)
+type keyBinding struct {
+	action string
+	key    *string
+	help   string
+}
+
+// keyBindings maps the configuration actions to the key variables, in the
+// order they are listed in the help dialog.
+var keyBindings = []keyBinding{
+	{"help", &KeyHelp, "Show help"},
+	{"quit", &KeyQuit, "Quit program"},
+	{"enter", &KeyEnter, "Enter directory / Open file / Confirm"},
+	{"cancel", &KeyCancel, "Cancel / Stop computing directory sizes or comparing"},
+	{"back", &KeyBack, "Upper directory"},
+	{"switch", &KeySwitch, "Switch panel"},
+	{"select", &KeySelect, "Select file"},
+	{"copy", &KeyCopy, "Copy files"},
+	{"copy_overwrite", &KeyCopyO, "Copy files with overwrite"},
+	{"move", &KeyMove, "Move files"},
+	{"move_overwrite", &KeyMoveO, "Move files with overwrite"},
+	{"delete", &KeyDelete, "Delete files"},
+	{"trash", &KeyTrash, "Move files to trash"},
+	{"mkdir", &KeyMkdir, "Create new directory"},
+	{"mkfile", &KeyMkfile, "Create new file"},
+	{"editor", &KeyEditor, "Open in editor"},
+	{"open_with", &KeyOpenWith, "Open with..."},
+	{"preview", &KeyPreview, "Toggle preview pane"},
+	{"preview_focus", &KeyPFocus, "Focus preview pane (scroll, expand/collapse trees)"},
+	{"sort", &KeySort, "Change sort order"},
+	{"theme", &KeyTheme, "Switch theme"},
+	{"columns", &KeyColumns, "Choose and resize the columns of the panel"},
+	{"format", &KeyFormat, "Switch to the next time format"},
+	{"size_format", &KeySizeFormat, "Switch to the next size format"},
+	{"dir_size", &KeyDirSize, "Compute the size of the selected (or all) directories"},
+	{"disk_usage", &KeyDiskUsage, "Analyze the disk usage of the directory"},
+	{"duplicates", &KeyDuplicates, "Find duplicate files in the directory"},
+	{"compare", &KeyCompare, "Compare the panels and select the differing files"},
+	{"sync", &KeySync, "Synchronize the directories of the panels"},
+	{"diff", &KeyDiff, "Show the differences between two files"},
+	{"pack", &KeyPack, "Pack the selection into an archive in the other panel"},
+	{"extract", &KeyExtract, "Extract the selected archives to the other panel"},
+	{"connect", &KeyConnect, "Connect the panel to a remote file system (SFTP, WebDAV, S3)"},
+	{"new_tab", &KeyNewTab, "Open the directory in a new tab"},
+	{"close_tab", &KeyCloseTab, "Close the tab"},
+	{"next_tab", &KeyNextTab, "Next tab"},
+	{"prev_tab", &KeyPrevTab, "Previous tab"},
+	{"filter", &KeyFilter, "Show only the names matching a filter in the tab"},
+	{"history_back", &KeyHistBack, "Go back to the previous directory of the tab"},
+	{"history_next", &KeyHistNext, "Go forward to the next directory of the tab"},
+	{"history", &KeyHistory, "List the directories recently visited in the tab"},
+	{"bookmarks", &KeyBookmarks, "Jump to a bookmarked directory, or edit the bookmarks"},
+	{"jump", &KeyJump, "Jump to a directory visited often or recently"},
+}
 
-var helpArray = [][2]string{
-	{KeyHelp, "Show help"},
-	{KeyQuit, "Quit program"},
-	{KeyEnter, "Enter directory / Open file / Confirm"},
-	{KeyCancel, "Cancel"},
-	{KeyBack, "Upper directory"},
-	{KeySwitch, "Switch panel"},
-	{KeySelect, "Select file"},
-	{KeyCopy, "Copy files"},
-	{KeyCopyO, "Copy files with overwrite"},
-	{KeyMove, "Move files"},
-	{KeyMoveO, "Move files with overwrite"},
-	{KeyDelete, "Delete files"},
-	{KeyTrash, "Move files to trash"},
-	{KeyMkdir, "Create new directory"},
-	{KeyMkfile, "Create new file"},
-	{KeyVscode, "Open in VSCode"},
+func applyKeys(keys map[string]string) {
+	for _, b := range keyBindings {
+		if key, ok := keys[b.action]; ok {
+			*b.key = key
+		}
+	}
 }
@@ -1,37 +1,77 @@
 package model
 
 import (
+	"fmt"
 	"strings"
 
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/x/ansi"
 )
 
 type ConfirmCallback func(*model) error
 type InputCallback func(string, *model) error
+type MenuCallback func(int, *model) error
 
 func (m *model) confirmDialog(text string, cb ConfirmCallback) {
 	m.confirmMessage = text
 	m.confirmCallback = cb
 }
 
+// confirmAction asks for confirmation when required by the configuration,
+// otherwise it runs the callback right away.
+func (m *model) confirmAction(required bool, text string, cb ConfirmCallback) {
+	if required {
+		m.confirmDialog(text, cb)
+		return
+	}
+	if err := cb(m); err != nil {
+		m.showError(err.Error())
+	}
+}
+
 func (m *model) inputDialog(text string, callback InputCallback) {
 	m.inputValue = ""
 	m.inputMessage = text
 	m.inputCallback = callback
 }
 
+func (m *model) menuDialog(title string, items []string, callback MenuCallback) {
+	m.menuTitle = title
+	m.menuItems = items
+	m.menuCursor = 0
+	m.menuCallback = callback
+}
+
+func (m *model) closeMenu() {
+	m.menuTitle = ""
+	m.menuItems = nil
+	m.menuCursor = 0
+	m.menuCallback = nil
+}
+
+// takePendingCmd returns the command scheduled by a dialog callback.
+func (m *model) takePendingCmd() tea.Cmd {
+	cmd := m.pendingCmd
+	m.pendingCmd = nil
+	return cmd
+}
+
 func (m *model) renderOverlayViews(modal string) string {
 	modalWidth := lipgloss.Width(modal)
 	modalHeight := lipgloss.Height(modal)
-	left := (m.windowWidth - modalWidth) / 2
-	top := (m.windowHeight - modalHeight) / 2
+	left := max(0, (m.windowWidth-modalWidth)/2)
+	top := max(0, (m.windowHeight-modalHeight)/2)
 
 	mainViewLines := strings.Split(m.view, "\n")
 	modalLines := strings.Split(modal, "\n")
 
 	for i, overlayLine := range modalLines {
-		bgLine := mainViewLines[i+top] // TODO: index handling
+		// The modals taller than the view are cut at the bottom
+		if i+top >= len(mainViewLines) {
+			break
+		}
+		bgLine := mainViewLines[i+top]
 		if len(bgLine) < left {
 			bgLine += strings.Repeat(" ", left-len(bgLine)) // add padding
 		}
@@ -85,21 +125,33 @@ func (m *model) renderAlertDialog(text string) string {
 	return m.renderOverlayViews(modal)
 }
 
+// helpPageSize returns the number of key bindings fitting in the help dialog.
+func (m *model) helpPageSize() int {
+	// Border, padding and margin of the dialog, the scroll hint and the button
+	return max(1, m.windowHeight-dialogBoxStyle.GetVerticalFrameSize()-4)
+}
+
 func (m *model) renderHelpDialog() string {
 	okButton := activeButtonStyle.Render("Ok")
 
 	text := ""
 
 	maxLength := 0
-	for _, item := range helpArray {
-		if len(item[0]) > maxLength {
-			maxLength = len(item[0])
+	for _, b := range keyBindings {
+		if len(*b.key) > maxLength {
+			maxLength = len(*b.key)
 		}
 	}
 
-	for _, item := range helpArray {
-		paddedKey := lipgloss.NewStyle().Bold(true).Render(item[0]) + strings.Repeat(" ", maxLength-len(item[0]))
-		text += paddedKey + " : " + item[1] + "\n"
+	pageSize := m.helpPageSize()
+	top := max(0, min(m.helpTop, len(keyBindings)-pageSize))
+	end := min(len(keyBindings), top+pageSize)
+	for _, b := range keyBindings[top:end] {
+		paddedKey := lipgloss.NewStyle().Bold(true).Render(*b.key) + strings.Repeat(" ", maxLength-len(*b.key))
+		text += paddedKey + " : " + b.help + "\n"
+	}
+	if top > 0 || end < len(keyBindings) {
+		text += lipgloss.NewStyle().Faint(true).Render(fmt.Sprintf("%d-%d of %d, up/down to scroll", top+1, end, len(keyBindings))) + "\n"
 	}
 
 	width := min(lipgloss.Width(text), m.windowWidth-20)
@@ -112,3 +164,49 @@ func (m *model) renderHelpDialog() string {
 
 	return m.renderOverlayViews(modal)
 }
+
+func (m *model) renderInputDialog(text string) string {
+	width := min(max(lipgloss.Width(text), 40), m.windowWidth-20)
+
+	question := lipgloss.NewStyle().Width(width).Align(lipgloss.Center).MarginBottom(1).Render(text)
+	input := inputStyle.Width(width).Render(m.inputValue + "▏")
+	ui := lipgloss.JoinVertical(lipgloss.Center, question, input)
+
+	modal := dialogBoxStyle.Render(ui)
+
+	return m.renderOverlayViews(modal)
+}
+
+func (m *model) renderMenuDialog() string {
+	maxItems := max(1, m.windowHeight-12)
+	start := 0
+	if m.menuCursor >= maxItems {
+		start = m.menuCursor - maxItems + 1
+	}
+	end := min(len(m.menuItems), start+maxItems)
+
+	width := lipgloss.Width(m.menuTitle)
+	for _, item := range m.menuItems {
+		width = max(width, lipgloss.Width(item)+2)
+	}
+	width = min(width, m.windowWidth-20)
+
+	itemStyle := lipgloss.NewStyle().Width(width).Padding(0, 1)
+	activeItemStyle := itemStyle.Foreground(themeColor(currentColors.ButtonFg)).Background(themeColor(currentColors.ButtonActiveBg)).Reverse(currentColors.ButtonActiveBg == "")
+
+	lines := []string{}
+	for i := start; i < end; i++ {
+		if i == m.menuCursor {
+			lines = append(lines, activeItemStyle.Render(m.menuItems[i]))
+		} else {
+			lines = append(lines, itemStyle.Render(m.menuItems[i]))
+		}
+	}
+
+	title := lipgloss.NewStyle().Width(width).Align(lipgloss.Center).Bold(true).MarginBottom(1).Render(m.menuTitle)
+	ui := lipgloss.JoinVertical(lipgloss.Left, title, strings.Join(lines, "\n"))
+
+	modal := dialogBoxStyle.Render(ui)
+
+	return m.renderOverlayViews(modal)
+}
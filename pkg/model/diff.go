@@ -0,0 +1,433 @@
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/evertras/bubble-table/table"
+	"github.com/sandrolain/gommander/pkg/diff"
+	"github.com/sandrolain/gommander/pkg/preview"
+	"github.com/sandrolain/gommander/pkg/rows"
+	"github.com/sandrolain/gommander/pkg/vfs"
+)
+
+// Larger files are not loaded by the diff viewer
+const diffMaxSize = 8 * 1024 * 1024
+
+// Lines of context kept above a hunk when jumping to it
+const diffContext = 3
+
+var whitespaceTitles = map[string]string{
+	diff.WhitespaceNone:   "whitespace: compared",
+	diff.WhitespaceChange: "whitespace: ignore changes",
+	diff.WhitespaceAll:    "whitespace: ignore all",
+}
+
+// diffView is the state of the diff overlay, shown in place of both panels.
+type diffView struct {
+	leftPath   string
+	rightPath  string
+	left       []string
+	right      []string
+	rows       []diffRow
+	hunks      []int
+	top        int
+	unified    bool
+	whitespace string
+	// summary replaces the lines for binary files
+	summary string
+}
+
+// diffRow is a row of the comparison with the spans of the changed lines,
+// computed once.
+type diffRow struct {
+	diff.Row
+	leftSpans  []diff.Span
+	rightSpans []diff.Span
+}
+
+var diffLineNoStyle = lipgloss.NewStyle().Faint(true)
+
+func diffStyle(op diff.Op) lipgloss.Style {
+	if currentTheme.Mono() {
+		return lipgloss.NewStyle()
+	}
+	if op == diff.Delete {
+		return diffDeleteStyle
+	}
+	return diffInsertStyle
+}
+
+// tableFile returns the only selected file of the table, or the highlighted
+// one when nothing is selected.
+func tableFile(t table.Model) (string, bool) {
+	selected := t.SelectedRows()
+	row := t.HighlightedRow()
+	if len(selected) == 1 {
+		row = selected[0]
+	} else if len(selected) > 1 {
+		return "", false
+	}
+	if row.Data["dir"] == true {
+		return "", false
+	}
+	path, err := rows.GetRowPath(row, true)
+	return path, err == nil && path != ""
+}
+
+// diffFiles returns the files to compare: the two selected in the active
+// panel, or one from each panel.
+func (m *model) diffFiles() (string, string, error) {
+	paths, err := m.getSelectedRowsPaths()
+	if err != nil {
+		return "", "", err
+	}
+	if len(paths) == 2 {
+		for _, path := range paths {
+			if info, err := statPath(path); err != nil || info.IsDir() {
+				return "", "", fmt.Errorf("Select two files to compare")
+			}
+		}
+		return paths[0], paths[1], nil
+	}
+
+	left, okLeft := tableFile(m.leftTable)
+	right, okRight := tableFile(m.rightTable)
+	if !okLeft || !okRight || left == right {
+		return "", "", fmt.Errorf("Select two files to compare, one per panel or two in the active panel")
+	}
+	return left, right, nil
+}
+
+func (m *model) startDiff() {
+	left, right, err := m.diffFiles()
+	if err != nil {
+		m.showError(err.Error())
+		return
+	}
+
+	d := &diffView{leftPath: left, rightPath: right, whitespace: diff.WhitespaceNone}
+	if err := d.load(); err != nil {
+		m.showError(fmt.Sprintf("Error comparing files: %v", err))
+		return
+	}
+	m.diff = d
+}
+
+// readDiffFile reads a file of any backend.
+func readDiffFile(path string) ([]byte, error) {
+	fsys, fpath, err := vfs.Resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := fsys.Stat(fpath)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > diffMaxSize {
+		return nil, fmt.Errorf("%s is too large (%d bytes)", path, info.Size())
+	}
+	r, err := fsys.Open(fpath)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+func (d *diffView) load() error {
+	a, err := readDiffFile(d.leftPath)
+	if err != nil {
+		return err
+	}
+	b, err := readDiffFile(d.rightPath)
+	if err != nil {
+		return err
+	}
+
+	if diff.IsBinary(a) || diff.IsBinary(b) {
+		offset, err := diff.FirstDifference(bytes.NewReader(a), bytes.NewReader(b))
+		if err != nil {
+			return err
+		}
+		d.summary = "Binary files are identical"
+		if offset >= 0 {
+			d.summary = fmt.Sprintf("Binary files differ, first difference at offset %d (0x%x)", offset, offset)
+		}
+		d.summary += fmt.Sprintf("\n\n%s: %d bytes\n%s: %d bytes", d.leftPath, len(a), d.rightPath, len(b))
+		return nil
+	}
+
+	d.left = diff.SplitLines(string(a))
+	d.right = diff.SplitLines(string(b))
+	d.compare()
+	return nil
+}
+
+func (d *diffView) compare() {
+	rows := diff.Rows(d.left, d.right, d.whitespace)
+	d.hunks = diff.Hunks(rows)
+	d.rows = make([]diffRow, len(rows))
+	for i, row := range rows {
+		d.rows[i] = diffRow{Row: row}
+		if row.Op == diff.Change {
+			d.rows[i].leftSpans, d.rows[i].rightSpans = diff.Inline(d.left[row.Left], d.right[row.Right])
+		}
+	}
+}
+
+// lines returns the rows of the view: the changes are split in the deleted
+// and the inserted lines in the unified mode, keeping the index of the other
+// line to highlight the changed spans.
+func (d *diffView) lines() []diffRow {
+	if !d.unified {
+		return d.rows
+	}
+
+	res := []diffRow{}
+	ins := []diffRow{}
+	for _, row := range d.rows {
+		switch row.Op {
+		case diff.Equal:
+			res = append(res, ins...)
+			ins = ins[:0]
+			res = append(res, row)
+		case diff.Delete:
+			res = append(res, row)
+		case diff.Insert:
+			ins = append(ins, row)
+		case diff.Change:
+			del, in := row, row
+			del.Op, in.Op = diff.Delete, diff.Insert
+			res = append(res, del)
+			ins = append(ins, in)
+		}
+	}
+	return append(res, ins...)
+}
+
+func (d *diffView) hunkLines() []int {
+	if !d.unified {
+		return d.hunks
+	}
+	lines := d.lines()
+	rows := make([]diff.Row, len(lines))
+	for i, line := range lines {
+		rows[i] = line.Row
+	}
+	return diff.Hunks(rows)
+}
+
+func (m *model) diffPageSize() int {
+	// Title, summary and key hints
+	return max(1, m.windowHeight-previewStyle.GetVerticalFrameSize()-3)
+}
+
+func (m *model) updateDiff(key string) tea.Cmd {
+	d := m.diff
+
+	switch key {
+	case KeyQuit:
+		return tea.Quit
+	case KeyHelp:
+		m.showHelp = !m.showHelp
+		return nil
+	case KeyCancel:
+		m.diff = nil
+		return nil
+	}
+
+	hunks := d.hunkLines()
+	switch key {
+	case "up", "k":
+		d.top--
+	case "down", "j":
+		d.top++
+	case "pgup":
+		d.top -= m.diffPageSize()
+	case "pgdown":
+		d.top += m.diffPageSize()
+	case "home", "g":
+		d.top = 0
+	case "end", "G":
+		d.top = len(d.lines())
+	case "n":
+		for _, h := range hunks {
+			if h-diffContext > d.top {
+				d.top = h - diffContext
+				break
+			}
+		}
+	case "p", "N":
+		for i := len(hunks) - 1; i >= 0; i-- {
+			if hunks[i]-diffContext < d.top {
+				d.top = hunks[i] - diffContext
+				break
+			}
+		}
+	case "u":
+		d.unified = !d.unified
+		d.top = 0
+	case "w":
+		for i, mode := range diff.WhitespaceModes {
+			if mode == d.whitespace {
+				d.whitespace = diff.WhitespaceModes[(i+1)%len(diff.WhitespaceModes)]
+				break
+			}
+		}
+		if d.summary == "" {
+			d.compare()
+		}
+		d.top = 0
+	}
+
+	d.top = max(0, min(d.top, len(d.lines())-m.diffPageSize()))
+	return nil
+}
+
+func (m *model) renderDiff() string {
+	d := m.diff
+	width := m.windowWidth - previewStyle.GetHorizontalFrameSize()
+	height := m.windowHeight - previewStyle.GetVerticalFrameSize()
+
+	title := tableHeaderStyle.Render(ansi.Truncate(fmt.Sprintf("%s ⇄ %s", d.leftPath, d.rightPath), width, "…"))
+	hints := lipgloss.NewStyle().Faint(true).Render(fmt.Sprintf("n/p: next/previous hunk  u: unified/side by side  w: whitespace  %s: close", KeyCancel))
+
+	if d.summary != "" {
+		lines := append([]string{title, ""}, strings.Split(d.summary, "\n")...)
+		return previewFocusedStyle.Render(preview.FitLines(lines, width, height-1) + "\n" + preview.FitLines([]string{hints}, width, 1))
+	}
+
+	added, removed := 0, 0
+	for _, row := range d.rows {
+		if row.Op == diff.Insert || row.Op == diff.Change {
+			added++
+		}
+		if row.Op == diff.Delete || row.Op == diff.Change {
+			removed++
+		}
+	}
+	summary := fmt.Sprintf("%d hunks, +%d -%d | %s", len(d.hunks), added, removed, whitespaceTitles[d.whitespace])
+	if len(d.hunks) == 0 {
+		summary = "The files are identical | " + whitespaceTitles[d.whitespace]
+	}
+	lines := []string{title, summary}
+
+	rows := d.lines()
+	numWidth := len(fmt.Sprintf("%d", max(len(d.left), len(d.right))))
+	for i := d.top; i < len(rows) && i < d.top+m.diffPageSize(); i++ {
+		if d.unified {
+			lines = append(lines, m.diffUnifiedLine(rows[i], numWidth, width))
+		} else {
+			lines = append(lines, m.diffSideBySideLine(rows[i], numWidth, width))
+		}
+	}
+
+	return previewFocusedStyle.Render(preview.FitLines(lines, width, height-1) + "\n" + preview.FitLines([]string{hints}, width, 1))
+}
+
+func expandTabs(line string) string {
+	return strings.ReplaceAll(line, "\t", "    ")
+}
+
+// diffText renders a line with the changed spans emphasized.
+func diffText(line string, spans []diff.Span, op diff.Op) string {
+	style := diffStyle(op)
+	if spans == nil {
+		return style.Render(expandTabs(line))
+	}
+
+	runes := []rune(line)
+	var b strings.Builder
+	for _, span := range spans {
+		text := expandTabs(string(runes[span.Start:span.End]))
+		if span.Changed {
+			b.WriteString(style.Reverse(true).Render(text))
+		} else {
+			b.WriteString(style.Render(text))
+		}
+	}
+	return b.String()
+}
+
+func (d *diffView) sideText(row diffRow, left bool) string {
+	index := row.Right
+	lines := d.right
+	op := diff.Insert
+	if left {
+		index = row.Left
+		lines = d.left
+		op = diff.Delete
+	}
+	if index < 0 {
+		return ""
+	}
+
+	line := lines[index]
+	if row.Op == diff.Equal {
+		return expandTabs(line)
+	}
+	if left {
+		return diffText(line, row.leftSpans, op)
+	}
+	return diffText(line, row.rightSpans, op)
+}
+
+func diffLineNo(index int, width int) string {
+	if index < 0 {
+		return diffLineNoStyle.Render(strings.Repeat(" ", width))
+	}
+	return diffLineNoStyle.Render(fmt.Sprintf("%*d", width, index+1))
+}
+
+func (m *model) diffSideBySideLine(row diffRow, numWidth int, width int) string {
+	d := m.diff
+	half := (width - 3) / 2
+
+	marker := " "
+	switch row.Op {
+	case diff.Delete:
+		marker = "<"
+	case diff.Insert:
+		marker = ">"
+	case diff.Change:
+		marker = "|"
+	}
+
+	side := func(index int, left bool) string {
+		text := diffLineNo(index, numWidth) + " " + d.sideText(row, left)
+		text = ansi.Truncate(text, half, "…")
+		if w := ansi.StringWidth(text); w < half {
+			text += strings.Repeat(" ", half-w)
+		}
+		return text
+	}
+	return side(row.Left, true) + " " + marker + " " + side(row.Right, false)
+}
+
+func (m *model) diffUnifiedLine(row diffRow, numWidth int, width int) string {
+	d := m.diff
+
+	left, right := row.Left, row.Right
+	prefix := "  "
+	text := ""
+	switch row.Op {
+	case diff.Equal:
+		text = expandTabs(d.left[row.Left])
+	case diff.Delete:
+		right = -1
+		prefix = diffStyle(diff.Delete).Render("- ")
+		text = d.sideText(row, true)
+	case diff.Insert:
+		left = -1
+		prefix = diffStyle(diff.Insert).Render("+ ")
+		text = d.sideText(row, false)
+	}
+	line := diffLineNo(left, numWidth) + " " + diffLineNo(right, numWidth) + " " + prefix + text
+	return ansi.Truncate(line, width, "…")
+}
@@ -0,0 +1,145 @@
+package model
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/evertras/bubble-table/table"
+	"github.com/sandrolain/gommander/pkg/dirsize"
+	"github.com/sandrolain/gommander/pkg/rows"
+)
+
+type dirSizeMsg dirsize.Result
+
+func waitDirSize(c *dirsize.Calculator) tea.Cmd {
+	return func() tea.Msg {
+		return dirSizeMsg(<-c.Results())
+	}
+}
+
+func dirPaths(tableRows []table.Row) []string {
+	paths := []string{}
+	for _, row := range tableRows {
+		if row.Data["dir"] != true || row.Data["name"] == ".." {
+			continue
+		}
+		if path, ok := row.Data["path"].(string); ok {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// autoDirSizes computes the sizes of the directories of the panel when
+// enabled in the configuration or when the total size column is shown.
+// The pending computations are cancelled when the panel changes directory.
+func (m *model) autoDirSizes(panel string) {
+	dir := m.leftPanelDir
+	t := m.leftTable
+	columns := m.layout.Left
+	if panel == "right" {
+		dir = m.rightPanelDir
+		t = m.rightTable
+		columns = m.layout.Right
+	}
+
+	if m.sizeDirs[panel] != dir {
+		m.dirSizes.Cancel(panel)
+		m.sizeDirs[panel] = dir
+	}
+
+	// The sizes of the directories of the other backends come with the rows
+	if !localDir(dir) {
+		return
+	}
+
+	if !m.config.DirSize.Auto && !hasColumnKey(columns, "dirsize") {
+		return
+	}
+	m.dirSizes.Compute(panel, dirPaths(t.GetVisibleRows()))
+}
+
+// computeDirSizes computes the sizes of the selected directories, or of all
+// the directories of the active panel if none is selected.
+func (m *model) computeDirSizes() {
+	t := m.leftTable
+	dir := m.leftPanelDir
+	if m.active == "right" {
+		t = m.rightTable
+		dir = m.rightPanelDir
+	}
+	if !localDir(dir) {
+		return
+	}
+
+	paths := dirPaths(t.SelectedRows())
+	if len(paths) == 0 {
+		paths = dirPaths(t.GetVisibleRows())
+	}
+	m.dirSizes.Compute(m.active, paths)
+}
+
+func (m *model) cancelDirSizes() bool {
+	if m.dirSizes.Pending("left") == 0 && m.dirSizes.Pending("right") == 0 {
+		return false
+	}
+	m.dirSizes.Cancel("left")
+	m.dirSizes.Cancel("right")
+	return true
+}
+
+// updateDirSize fills the size of the directory in the rows of both panels
+// and sorts them again once all the requested sizes are computed.
+func (m *model) updateDirSize(res dirSizeMsg) {
+	if res.Err != nil {
+		return
+	}
+
+	size := m.rowsOptions.Format.FormatSize(res.Size)
+	for _, t := range []table.Model{m.leftTable, m.rightTable} {
+		// The row data is shared with the table, so it is updated in place
+		for _, row := range t.GetVisibleRows() {
+			if row.Data["path"] != res.Path {
+				continue
+			}
+			row.Data["size"] = size
+			row.Data["usize"] = res.Size
+			row.Data["dirsize"] = size
+			row.Data["udirsize"] = res.Size
+		}
+	}
+
+	if m.dirSizes.Pending(res.Group) > 0 {
+		return
+	}
+	// Both panels, as they can list the same directory
+	if m.leftSort.By == "size" {
+		m.leftTable = sortTable(m.leftTable, m.leftSort)
+	}
+	if m.rightSort.By == "size" {
+		m.rightTable = sortTable(m.rightTable, m.rightSort)
+	}
+}
+
+// sortTable sorts the rows keeping the selection and the highlighted row.
+func sortTable(t table.Model, opts rows.SortOptions) table.Model {
+	highlighted := t.HighlightedRow().Data["path"]
+
+	sorted := append([]table.Row{}, t.GetVisibleRows()...)
+	rows.SortRows(sorted, opts)
+	t = t.WithRows(sorted)
+
+	for i, row := range sorted {
+		if row.Data["path"] == highlighted {
+			return t.WithHighlightedRow(i)
+		}
+	}
+	return t
+}
+
+func (m *model) dirSizesStatus(panel string) string {
+	if n := m.dirSizes.Pending(panel); n > 0 {
+		return fmt.Sprintf(" | Sizing: %d", n)
+	}
+	return ""
+}
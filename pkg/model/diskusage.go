@@ -0,0 +1,291 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/sandrolain/gommander/pkg/diskusage"
+	"github.com/sandrolain/gommander/pkg/fs"
+	"github.com/sandrolain/gommander/pkg/preview"
+)
+
+const duBarWidth = 12
+
+// diskUsage is the state of the disk usage analyzer, shown in place of the
+// table of the panel it was started from.
+type diskUsage struct {
+	panel    string
+	root     *diskusage.Node
+	current  *diskusage.Node
+	cursor   int
+	top      int
+	scanning bool
+	progress diskusage.Progress
+	cancel   context.CancelFunc
+	msgs     chan tea.Msg
+}
+
+type duProgressMsg struct {
+	du       *diskUsage
+	progress diskusage.Progress
+}
+
+type duDoneMsg struct {
+	du   *diskUsage
+	root *diskusage.Node
+	err  error
+}
+
+func waitDiskUsage(du *diskUsage) tea.Cmd {
+	return func() tea.Msg {
+		return <-du.msgs
+	}
+}
+
+// startDiskUsage scans the directory of the active panel in the background.
+func (m *model) startDiskUsage() tea.Cmd {
+	dir := m.leftPanelDir
+	if m.active == "right" {
+		dir = m.rightPanelDir
+	}
+	if m.refuseRemote(dir) {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	du := &diskUsage{
+		panel:    m.active,
+		scanning: true,
+		cancel:   cancel,
+		msgs:     make(chan tea.Msg, 1),
+	}
+	m.du = du
+
+	go func() {
+		root, err := diskusage.Scan(ctx, dir, func(p diskusage.Progress) {
+			// Drop the report if the previous one was not handled yet
+			select {
+			case du.msgs <- duProgressMsg{du: du, progress: p}:
+			default:
+			}
+		})
+		du.msgs <- duDoneMsg{du: du, root: root, err: err}
+	}()
+
+	return waitDiskUsage(du)
+}
+
+func (m *model) stopDiskUsage() {
+	if m.du != nil {
+		m.du.cancel()
+		m.du = nil
+	}
+}
+
+func (m *model) updateDiskUsageScan(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case duProgressMsg:
+		if msg.du != m.du {
+			return nil
+		}
+		m.du.progress = msg.progress
+		return waitDiskUsage(m.du)
+	case duDoneMsg:
+		if msg.du != m.du {
+			return nil
+		}
+		if msg.err != nil {
+			m.du = nil
+			if !errors.Is(msg.err, context.Canceled) {
+				m.showError(fmt.Sprintf("Error scanning directory: %v", msg.err))
+			}
+			return nil
+		}
+		m.du.scanning = false
+		m.du.root = msg.root
+		m.du.current = msg.root
+	}
+	return nil
+}
+
+func (m *model) updateDiskUsage(key string) tea.Cmd {
+	du := m.du
+
+	switch key {
+	case KeyQuit:
+		return tea.Quit
+	case KeyHelp:
+		m.showHelp = !m.showHelp
+		return nil
+	case KeyCancel:
+		m.stopDiskUsage()
+		return nil
+	}
+
+	if du.scanning {
+		return nil
+	}
+
+	children := du.current.Children
+	switch key {
+	case "up", "k":
+		du.cursor--
+	case "down", "j":
+		du.cursor++
+	case "pgup":
+		du.cursor -= m.duPageSize()
+	case "pgdown":
+		du.cursor += m.duPageSize()
+	case "home", "g":
+		du.cursor = 0
+	case "end", "G":
+		du.cursor = len(children) - 1
+	case KeyEnter, "right", "l":
+		if du.cursor < len(children) && children[du.cursor].Dir {
+			du.current = children[du.cursor]
+			du.cursor = 0
+			du.top = 0
+		}
+	case KeyBack, "left", "h":
+		if du.current.Parent != nil {
+			prev := du.current
+			du.current = du.current.Parent
+			du.cursor = 0
+			for i, child := range du.current.Children {
+				if child == prev {
+					du.cursor = i
+				}
+			}
+		}
+	case KeyDelete:
+		m.duRemove(false)
+	case KeyTrash:
+		m.duRemove(true)
+	}
+
+	du.scroll(m.duPageSize())
+	return nil
+}
+
+// scroll keeps the cursor on an entry of the directory and in the page
+// shown.
+func (du *diskUsage) scroll(pageSize int) {
+	du.cursor = max(0, min(du.cursor, len(du.current.Children)-1))
+	if du.cursor < du.top {
+		du.top = du.cursor
+	}
+	if du.cursor >= du.top+pageSize {
+		du.top = du.cursor - pageSize + 1
+	}
+}
+
+// duRemove deletes or trashes the highlighted entry, updating the totals of
+// the tree.
+func (m *model) duRemove(trash bool) {
+	du := m.du
+	if du.cursor >= len(du.current.Children) {
+		return
+	}
+	node := du.current.Children[du.cursor]
+
+	text := "Are you sure you want to delete\n%s?"
+	required := m.config.Confirm.Delete
+	if trash {
+		text = "Are you sure you want move to trash\n%s?"
+		required = m.config.Confirm.Trash
+	}
+
+	m.confirmAction(required, fmt.Sprintf(text, node.Path), func(m *model) error {
+		var err error
+		if trash {
+			err = fs.TrashFiles([]string{node.Path})
+		} else {
+			err = fs.DeleteTrees([]string{node.Path})
+		}
+		if err != nil {
+			return fmt.Errorf("Error removing file: %v", err)
+		}
+
+		node.Remove()
+		if m.du != nil {
+			m.du.scroll(m.duPageSize())
+		}
+		m.refreshTablesRows(true, true)
+		return nil
+	})
+}
+
+func (m *model) duPageSize() int {
+	// Title, total, blank line and key hints
+	return max(1, m.previewHeight-4)
+}
+
+func (m *model) renderDiskUsage() string {
+	du := m.du
+	width := m.previewWidth
+	height := m.previewHeight
+
+	if du.scanning {
+		lines := []string{
+			"Scanning...",
+			"",
+			fmt.Sprintf("Files: %d", du.progress.Files),
+			fmt.Sprintf("Size: %s", m.rowsOptions.Format.FormatSize(du.progress.Bytes)),
+			"",
+			lipgloss.NewStyle().Faint(true).Render("esc: stop"),
+		}
+		return previewFocusedStyle.Render(preview.FitLines(lines, width, height))
+	}
+
+	node := du.current
+	lines := []string{
+		tableHeaderStyle.Render(ansi.Truncate(node.Path, width, "…")),
+		fmt.Sprintf("Total: %s in %d items", m.rowsOptions.Format.FormatSize(node.Size), node.Items),
+		"",
+	}
+
+	pageSize := m.duPageSize()
+	for i := du.top; i < len(node.Children) && i < du.top+pageSize; i++ {
+		child := node.Children[i]
+		line := m.duLine(child, width)
+		if i == du.cursor {
+			line = highlightStyle().Width(width).Render(ansi.Strip(line))
+		}
+		lines = append(lines, line)
+	}
+
+	if len(node.Children) == 0 {
+		lines = append(lines, lipgloss.NewStyle().Faint(true).Render("Empty directory"))
+	}
+
+	hints := fmt.Sprintf("%s: open  %s: up  %s: trash  %s: delete  %s: close", KeyEnter, KeyBack, KeyTrash, KeyDelete, KeyCancel)
+	content := preview.FitLines(lines, width, height-1) + "\n" + preview.FitLines([]string{lipgloss.NewStyle().Faint(true).Render(hints)}, width, 1)
+	return previewFocusedStyle.Render(content)
+}
+
+func (m *model) duLine(n *diskusage.Node, width int) string {
+	percent := n.Percent()
+	filled := int(percent * duBarWidth / 100)
+	bar := "[" + strings.Repeat("#", filled) + strings.Repeat(" ", duBarWidth-filled) + "]"
+
+	name := n.Name
+	style := lipgloss.NewStyle().Foreground(themeColor(currentColors.File))
+	items := ""
+	if n.Dir {
+		name += string(filepath.Separator)
+		style = lipgloss.NewStyle().Foreground(themeColor(currentColors.Directory)).Bold(currentColors.Directory == "")
+		items = fmt.Sprintf("%d", n.Items)
+	}
+	if n.Err != nil {
+		name += " (unreadable)"
+	}
+
+	prefix := fmt.Sprintf("%10s %5.1f%% %s %7s ", m.rowsOptions.Format.FormatSize(n.Size), percent, bar, items)
+	return prefix + style.Render(ansi.Truncate(name, max(0, width-ansi.StringWidth(prefix)), "…"))
+}
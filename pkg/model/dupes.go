@@ -0,0 +1,496 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/sandrolain/gommander/pkg/dupes"
+	"github.com/sandrolain/gommander/pkg/fs"
+	"github.com/sandrolain/gommander/pkg/preview"
+)
+
+// duplicates is the state of the duplicate finder, shown in place of the
+// table of the panel it was started from.
+type duplicates struct {
+	panel    string
+	root     string
+	groups   []dupes.Group
+	marked   map[string]bool
+	cursor   int
+	top      int
+	scanning bool
+	progress dupes.Progress
+	cancel   context.CancelFunc
+	msgs     chan tea.Msg
+}
+
+// dupItem is a line of the list: a group header when file is -1.
+type dupItem struct {
+	group int
+	file  int
+}
+
+type dupProgressMsg struct {
+	dup      *duplicates
+	progress dupes.Progress
+}
+
+type dupDoneMsg struct {
+	dup    *duplicates
+	groups []dupes.Group
+	err    error
+}
+
+func waitDuplicates(dup *duplicates) tea.Cmd {
+	return func() tea.Msg {
+		return <-dup.msgs
+	}
+}
+
+// startDuplicates searches the directory of the active panel for duplicate
+// files in the background.
+func (m *model) startDuplicates() tea.Cmd {
+	dir := m.leftPanelDir
+	if m.active == "right" {
+		dir = m.rightPanelDir
+	}
+	if m.refuseRemote(dir) {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	dup := &duplicates{
+		panel:    m.active,
+		root:     dir,
+		marked:   map[string]bool{},
+		scanning: true,
+		cancel:   cancel,
+		msgs:     make(chan tea.Msg, 1),
+	}
+	m.dup = dup
+
+	go func() {
+		groups, err := dupes.Find(ctx, dir, func(p dupes.Progress) {
+			// Drop the report if the previous one was not handled yet
+			select {
+			case dup.msgs <- dupProgressMsg{dup: dup, progress: p}:
+			default:
+			}
+		})
+		dup.msgs <- dupDoneMsg{dup: dup, groups: groups, err: err}
+	}()
+
+	return waitDuplicates(dup)
+}
+
+func (m *model) stopDuplicates() {
+	if m.dup != nil {
+		m.dup.cancel()
+		m.dup = nil
+	}
+}
+
+func (m *model) updateDuplicatesScan(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case dupProgressMsg:
+		if msg.dup != m.dup {
+			return nil
+		}
+		m.dup.progress = msg.progress
+		return waitDuplicates(m.dup)
+	case dupDoneMsg:
+		if msg.dup != m.dup {
+			return nil
+		}
+		if msg.err != nil {
+			m.dup = nil
+			if !errors.Is(msg.err, context.Canceled) {
+				m.showError(fmt.Sprintf("Error searching duplicates: %v", msg.err))
+			}
+			return nil
+		}
+		m.dup.scanning = false
+		m.dup.groups = msg.groups
+		m.dup.cursor = m.dup.nextFile(0, 1)
+		m.dup.scroll(m.dupPageSize())
+	}
+	return nil
+}
+
+func (dup *duplicates) items() []dupItem {
+	items := []dupItem{}
+	for g, group := range dup.groups {
+		items = append(items, dupItem{group: g, file: -1})
+		for f := range group.Files {
+			items = append(items, dupItem{group: g, file: f})
+		}
+	}
+	return items
+}
+
+// nextFile returns the index of the first file item from i in the given
+// direction, skipping the group headers.
+func (dup *duplicates) nextFile(i int, dir int) int {
+	items := dup.items()
+	if len(items) == 0 {
+		return 0
+	}
+	i = max(0, min(i, len(items)-1))
+	for j := i; j >= 0 && j < len(items); j += dir {
+		if items[j].file >= 0 {
+			return j
+		}
+	}
+	// Nothing in that direction, search the other one
+	for j := i; j >= 0 && j < len(items); j -= dir {
+		if items[j].file >= 0 {
+			return j
+		}
+	}
+	return 0
+}
+
+func (dup *duplicates) current() (dupItem, bool) {
+	items := dup.items()
+	if dup.cursor < 0 || dup.cursor >= len(items) || items[dup.cursor].file < 0 {
+		return dupItem{}, false
+	}
+	return items[dup.cursor], true
+}
+
+// toggleMark marks or unmarks a file, refusing to mark the last unmarked
+// copy of a group.
+func (dup *duplicates) toggleMark(item dupItem) {
+	group := dup.groups[item.group]
+	path := group.Files[item.file]
+	if dup.marked[path] {
+		delete(dup.marked, path)
+		return
+	}
+	if dup.unmarked(group) > 1 {
+		dup.marked[path] = true
+	}
+}
+
+func (dup *duplicates) unmarked(group dupes.Group) int {
+	n := 0
+	for _, path := range group.Files {
+		if !dup.marked[path] {
+			n++
+		}
+	}
+	return n
+}
+
+// markCopies marks all the files of each group but the first one.
+func (dup *duplicates) markCopies() {
+	for _, group := range dup.groups {
+		for i, path := range group.Files {
+			if i == 0 {
+				delete(dup.marked, path)
+			} else {
+				dup.marked[path] = true
+			}
+		}
+	}
+}
+
+func (dup *duplicates) markedFiles() []string {
+	files := []string{}
+	for _, group := range dup.groups {
+		for _, path := range group.Files {
+			if dup.marked[path] {
+				files = append(files, path)
+			}
+		}
+	}
+	return files
+}
+
+func (dup *duplicates) markedSize() uint64 {
+	var size uint64
+	for _, group := range dup.groups {
+		for _, path := range group.Files {
+			if dup.marked[path] {
+				size += group.Size
+			}
+		}
+	}
+	return size
+}
+
+// removeFiles drops the files from the groups, and the groups left with a
+// single file.
+func (dup *duplicates) removeFiles(paths []string) {
+	removed := map[string]bool{}
+	for _, path := range paths {
+		removed[path] = true
+		delete(dup.marked, path)
+	}
+
+	groups := []dupes.Group{}
+	for _, group := range dup.groups {
+		files := []string{}
+		for _, path := range group.Files {
+			if !removed[path] {
+				files = append(files, path)
+			}
+		}
+		if len(files) > 1 {
+			groups = append(groups, dupes.Group{Size: group.Size, Files: files})
+		} else {
+			for _, path := range files {
+				delete(dup.marked, path)
+			}
+		}
+	}
+	dup.groups = groups
+	dup.cursor = dup.nextFile(dup.cursor, 1)
+}
+
+func (m *model) updateDuplicates(key string) tea.Cmd {
+	dup := m.dup
+
+	switch key {
+	case KeyQuit:
+		return tea.Quit
+	case KeyHelp:
+		m.showHelp = !m.showHelp
+		return nil
+	case KeyCancel:
+		m.stopDuplicates()
+		return nil
+	}
+
+	if dup.scanning {
+		return nil
+	}
+
+	count := len(dup.items())
+	switch key {
+	case "up", "k":
+		dup.cursor = dup.nextFile(dup.cursor-1, -1)
+	case "down", "j":
+		dup.cursor = dup.nextFile(dup.cursor+1, 1)
+	case "pgup":
+		dup.cursor = dup.nextFile(dup.cursor-m.dupPageSize(), -1)
+	case "pgdown":
+		dup.cursor = dup.nextFile(dup.cursor+m.dupPageSize(), 1)
+	case "home", "g":
+		dup.cursor = dup.nextFile(0, 1)
+	case "end", "G":
+		dup.cursor = dup.nextFile(count-1, -1)
+	case KeySelect:
+		if item, ok := dup.current(); ok {
+			dup.toggleMark(item)
+			dup.cursor = dup.nextFile(dup.cursor+1, 1)
+		}
+	case "a":
+		dup.markCopies()
+	case "u":
+		dup.marked = map[string]bool{}
+	case "L":
+		m.dupLink()
+	case KeyDelete:
+		m.dupRemove(false)
+	case KeyTrash:
+		m.dupRemove(true)
+	}
+	dup.scroll(m.dupPageSize())
+	return nil
+}
+
+// scroll keeps the cursor in the page shown.
+func (dup *duplicates) scroll(pageSize int) {
+	items := dup.items()
+	if dup.cursor < dup.top {
+		dup.top = dup.cursor
+	}
+	if dup.cursor >= dup.top+pageSize {
+		dup.top = dup.cursor - pageSize + 1
+	}
+	// Show the header of the group of the first visible file
+	if dup.top > 0 && dup.top < len(items) && items[dup.top].file == 0 && dup.cursor < dup.top+pageSize-1 {
+		dup.top--
+	}
+}
+
+// dupRemove deletes or trashes the marked files.
+func (m *model) dupRemove(trash bool) {
+	files := m.dup.markedFiles()
+	if len(files) == 0 {
+		return
+	}
+
+	text := "Are you sure you want to delete %d duplicate files (%s)?"
+	required := m.config.Confirm.Delete
+	if trash {
+		text = "Are you sure you want move to trash %d duplicate files (%s)?"
+		required = m.config.Confirm.Trash
+	}
+	text = fmt.Sprintf(text, len(files), m.rowsOptions.Format.FormatSize(m.dup.markedSize()))
+
+	dup := m.dup
+	m.confirmAction(required, text, func(m *model) error {
+		removed := []string{}
+		var err error
+		for _, path := range files {
+			if trash {
+				err = fs.TrashFile(path)
+			} else {
+				err = fs.DeleteFile(path)
+			}
+			if err != nil {
+				break
+			}
+			removed = append(removed, path)
+		}
+
+		dup.removeFiles(removed)
+		dup.scroll(m.dupPageSize())
+		m.refreshTablesRows(true, true)
+		if err != nil {
+			return fmt.Errorf("Error removing file: %v", err)
+		}
+		return nil
+	})
+}
+
+// dupLink replaces the marked files with hard links to the unmarked copy of
+// their group.
+func (m *model) dupLink() {
+	files := m.dup.markedFiles()
+	if len(files) == 0 {
+		return
+	}
+
+	text := fmt.Sprintf("Replace %d duplicate files with hard links (%s)?", len(files), m.rowsOptions.Format.FormatSize(m.dup.markedSize()))
+
+	dup := m.dup
+	m.confirmAction(m.config.Confirm.Delete, text, func(m *model) error {
+		linked := []string{}
+		var err error
+	groups:
+		for _, group := range dup.groups {
+			source := ""
+			for _, path := range group.Files {
+				if !dup.marked[path] {
+					source = path
+					break
+				}
+			}
+			for _, path := range group.Files {
+				if !dup.marked[path] {
+					continue
+				}
+				err = fs.ReplaceWithHardLink(path, source)
+				if err != nil {
+					break groups
+				}
+				linked = append(linked, path)
+			}
+		}
+
+		// The linked files share the data with the source, they are no longer
+		// duplicates
+		dup.removeFiles(linked)
+		dup.scroll(m.dupPageSize())
+		m.refreshTablesRows(true, true)
+		if err != nil {
+			return fmt.Errorf("Error creating hard link: %v", err)
+		}
+		return nil
+	})
+}
+
+func (m *model) dupPageSize() int {
+	// Title, summary, blank line and key hints
+	return max(1, m.previewHeight-4)
+}
+
+func (m *model) dupSummary() string {
+	dup := m.dup
+	files := 0
+	var reclaimable uint64
+	for _, group := range dup.groups {
+		files += len(group.Files) - 1
+		reclaimable += group.Reclaimable()
+	}
+
+	format := m.rowsOptions.Format
+	summary := fmt.Sprintf("%d groups, %d duplicates, %s reclaimable", len(dup.groups), files, format.FormatSize(reclaimable))
+	if marked := dup.markedFiles(); len(marked) > 0 {
+		summary += fmt.Sprintf(" | Marked: %d (%s)", len(marked), format.FormatSize(dup.markedSize()))
+	}
+	return summary
+}
+
+func (m *model) renderDuplicates() string {
+	dup := m.dup
+	width := m.previewWidth
+	height := m.previewHeight
+
+	if dup.scanning {
+		progress := fmt.Sprintf("Files: %d", dup.progress.Files)
+		if dup.progress.Phase != dupes.PhaseScan {
+			progress = fmt.Sprintf("Files: %d/%d", dup.progress.Done, dup.progress.Total)
+		}
+		lines := []string{
+			"Searching duplicates...",
+			"",
+			dup.progress.Phase,
+			progress,
+			"",
+			lipgloss.NewStyle().Faint(true).Render("esc: stop"),
+		}
+		return previewFocusedStyle.Render(preview.FitLines(lines, width, height))
+	}
+
+	lines := []string{
+		tableHeaderStyle.Render(ansi.Truncate("Duplicates in "+dup.root, width, "…")),
+		m.dupSummary(),
+		"",
+	}
+
+	items := dup.items()
+	pageSize := m.dupPageSize()
+	format := m.rowsOptions.Format
+	for i := dup.top; i < len(items) && i < dup.top+pageSize; i++ {
+		item := items[i]
+		group := dup.groups[item.group]
+		if item.file < 0 {
+			header := fmt.Sprintf("%d × %s, %s reclaimable", len(group.Files), format.FormatSize(group.Size), format.FormatSize(group.Reclaimable()))
+			lines = append(lines, lipgloss.NewStyle().Bold(true).Foreground(themeColor(currentColors.Header)).Render(header))
+			continue
+		}
+
+		path := group.Files[item.file]
+		mark := "  "
+		if dup.marked[path] {
+			mark = "* "
+		}
+		if rel, err := filepath.Rel(dup.root, path); err == nil {
+			path = rel
+		}
+		line := mark + path
+		if i == dup.cursor {
+			line = highlightStyle().Width(width).Render(ansi.Truncate(line, width, "…"))
+		} else if dup.marked[group.Files[item.file]] {
+			line = lipgloss.NewStyle().Foreground(themeColor(currentColors.FooterValue)).Render(line)
+		}
+		lines = append(lines, line)
+	}
+
+	if len(items) == 0 {
+		lines = append(lines, lipgloss.NewStyle().Faint(true).Render("No duplicate files found"))
+	}
+
+	hints := fmt.Sprintf("%s: mark  a: mark copies  u: unmark  L: hard link  %s: trash  %s: delete  %s: close", KeySelect, KeyTrash, KeyDelete, KeyCancel)
+	content := preview.FitLines(lines, width, height-1) + "\n" + preview.FitLines([]string{lipgloss.NewStyle().Faint(true).Render(hints)}, width, 1)
+	return previewFocusedStyle.Render(content)
+}
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sandrolain/gommander/pkg/rows"
+)
+
+// filterDialog asks for the filter of the names shown in the active tab.
+func (m *model) filterDialog() {
+	m.inputDialog("Show only the names containing the text or matching the wildcards (empty to show all):", func(value string, m *model) error {
+		value = strings.TrimSpace(value)
+		if !rows.ValidFilter(value) {
+			return fmt.Errorf("Invalid filter %q", value)
+		}
+		*m.panelFilter(m.active) = value
+		m.refreshTablesRows(true, false)
+		return nil
+	})
+	m.inputValue = *m.panelFilter(m.active)
+}
@@ -0,0 +1,35 @@
+package model
+
+import (
+	"slices"
+
+	"github.com/sandrolain/gommander/pkg/rows"
+)
+
+// cycleTimeFormat switches to the next time format, the Go layout of the
+// configuration included.
+func (m *model) cycleTimeFormat() {
+	formats := rows.TimeFormats
+	if custom := m.config.Format.Time; !slices.Contains(formats, custom) {
+		formats = append(slices.Clone(formats), custom)
+	}
+	m.rowsOptions.Format.Time = nextFormat(formats, m.rowsOptions.Format.Time)
+	m.refreshTablesRows(true, true)
+}
+
+func (m *model) cycleSizeFormat() {
+	m.rowsOptions.Format.Size = nextFormat(rows.SizeFormats, m.rowsOptions.Format.Size)
+	m.refreshTablesRows(true, true)
+}
+
+func nextFormat(formats []string, current string) string {
+	i := slices.Index(formats, current)
+	return formats[(i+1)%len(formats)]
+}
+
+func markCurrent(item string, current bool) string {
+	if current {
+		return item + " *"
+	}
+	return item
+}
@@ -0,0 +1,141 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/sandrolain/gommander/pkg/vfs"
+)
+
+// maxHistory is the number of directories kept in the history of a tab.
+const maxHistory = 50
+
+// history is the navigation of a tab: the directories to go back and
+// forward to, the recently visited ones, and the row highlighted in each
+// directory when leaving it.
+type history struct {
+	back    []string
+	forward []string
+	recent  []string
+	cursors map[string]string
+}
+
+func newHistory() *history {
+	return &history{cursors: map[string]string{}}
+}
+
+func (h *history) clone() *history {
+	if h == nil {
+		return nil
+	}
+	c := &history{
+		back:    append([]string{}, h.back...),
+		forward: append([]string{}, h.forward...),
+		recent:  append([]string{}, h.recent...),
+		cursors: make(map[string]string, len(h.cursors)),
+	}
+	for dir, name := range h.cursors {
+		c.cursors[dir] = name
+	}
+	return c
+}
+
+// visit records the move from a directory to another one, dropping the
+// directories to go forward to.
+func (h *history) visit(from string, to string) {
+	if from == "" || from == to {
+		return
+	}
+	h.back = append(h.back, from)
+	if len(h.back) > maxHistory {
+		h.back = h.back[len(h.back)-maxHistory:]
+	}
+	h.forward = nil
+}
+
+// shown moves the directory to the top of the recently visited ones.
+func (h *history) shown(dir string) {
+	recent := []string{dir}
+	for _, d := range h.recent {
+		if d != dir && len(recent) < maxHistory {
+			recent = append(recent, d)
+		}
+	}
+	h.recent = recent
+}
+
+func (m *model) panelHistory(panel string) *history {
+	h := &m.rightHistory
+	if panel == "left" {
+		h = &m.leftHistory
+	}
+	if *h == nil {
+		*h = newHistory()
+	}
+	return *h
+}
+
+// checkDir reports an error when the path is not a directory that can be
+// shown.
+func checkDir(path string) error {
+	fsys, fpath, err := vfs.ResolveDir(path)
+	if err != nil {
+		return err
+	}
+	info, err := fsys.Stat(fpath)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%v is not a directory", path)
+	}
+	return nil
+}
+
+// moveHistory shows the previous directory of the active tab, or the next
+// one when going forward. The directories that are gone are dropped.
+func (m *model) moveHistory(forward bool) error {
+	h := m.panelHistory(m.active)
+	from, to := &h.back, &h.forward
+	if forward {
+		from, to = to, from
+	}
+	if len(*from) == 0 {
+		return nil
+	}
+
+	dir := (*from)[len(*from)-1]
+	*from = (*from)[:len(*from)-1]
+	if err := checkDir(dir); err != nil {
+		return fmt.Errorf("Error opening %s: %v", dir, err)
+	}
+
+	current := m.panelDir(m.active)
+	if err := m.enterDir(dir, watchDir(dir)); err != nil {
+		return err
+	}
+	*to = append(*to, current)
+	return nil
+}
+
+// historyDialog lists the directories recently visited in the active tab.
+func (m *model) historyDialog() {
+	current := m.panelDir(m.active)
+	dirs := []string{}
+	for _, dir := range m.panelHistory(m.active).recent {
+		if dir != current {
+			dirs = append(dirs, dir)
+		}
+	}
+	if len(dirs) == 0 {
+		m.showError("No directories in the history")
+		return
+	}
+
+	m.menuDialog("History", dirs, func(i int, m *model) error {
+		if err := checkDir(dirs[i]); err != nil {
+			return fmt.Errorf("Error opening %s: %v", dirs[i], err)
+		}
+		_, err := m.enterFile(dirs[i])
+		return err
+	})
+}
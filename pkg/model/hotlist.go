@@ -0,0 +1,222 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/sandrolain/gommander/pkg/bookmarks"
+	"github.com/sandrolain/gommander/pkg/vfs"
+)
+
+// jumpTo shows the directory in the active panel, connecting first to the
+// remote ones. The archives are opened like the local directories.
+func (m *model) jumpTo(path string) error {
+	if root := vfs.Root(path); root != "" {
+		m.startConnection(root[strings.Index(root, "://")+3:], path)
+		return nil
+	}
+	if err := checkDir(path); err != nil {
+		return fmt.Errorf("Error opening %s: %v", path, err)
+	}
+	_, err := m.enterFile(path)
+	return err
+}
+
+func (m *model) hotlistDialog() {
+	m.hotlistOpen = true
+	m.hotlistCursor = 0
+}
+
+func (m *model) saveBookmarks() {
+	if err := bookmarks.Save(m.bookmarks); err != nil {
+		m.showError(fmt.Sprintf("Error saving the bookmarks: %v", err))
+	}
+}
+
+func (m *model) jumpToBookmark(i int) {
+	m.hotlistOpen = false
+	if err := m.jumpTo(m.bookmarks[i].Path); err != nil {
+		m.showError(err.Error())
+	}
+}
+
+// updateHotlist handles the keys of the hotlist, the shortcuts of the
+// bookmarks being the letters and the digits.
+func (m *model) updateHotlist(key string) tea.Cmd {
+	items := m.bookmarks
+	i := m.hotlistCursor
+
+	switch key {
+	case "up":
+		m.hotlistCursor = max(0, i-1)
+	case "down":
+		m.hotlistCursor = max(0, min(len(items)-1, i+1))
+	case "home":
+		m.hotlistCursor = 0
+	case "end":
+		m.hotlistCursor = max(0, len(items)-1)
+	case KeyCancel:
+		m.hotlistOpen = false
+	case KeyEnter:
+		if len(items) > 0 {
+			m.jumpToBookmark(i)
+		}
+	case "+", "insert":
+		m.addBookmarkDialog()
+	case "-", "delete":
+		if len(items) == 0 {
+			return nil
+		}
+		m.bookmarks = slices.Delete(items, i, i+1)
+		m.hotlistCursor = max(0, min(i, len(m.bookmarks)-1))
+		m.saveBookmarks()
+	case "shift+up":
+		if i > 0 {
+			items[i-1], items[i] = items[i], items[i-1]
+			m.hotlistCursor--
+			m.saveBookmarks()
+		}
+	case "shift+down":
+		if i < len(items)-1 {
+			items[i+1], items[i] = items[i], items[i+1]
+			m.hotlistCursor++
+			m.saveBookmarks()
+		}
+	case "ctrl+r":
+		if len(items) > 0 {
+			m.renameBookmarkDialog(i)
+		}
+	case "ctrl+k":
+		if len(items) > 0 {
+			m.bookmarkKeyDialog(i)
+		}
+	case "ctrl+g":
+		m.importBookmarks()
+	default:
+		if j, ok := items.FindKey(key); ok {
+			m.jumpToBookmark(j)
+		}
+	}
+	return m.takePendingCmd()
+}
+
+// addBookmarkDialog bookmarks the directory of the active panel.
+func (m *model) addBookmarkDialog() {
+	path := m.panelDir(m.active)
+	if j, ok := m.bookmarks.FindPath(path); ok {
+		m.hotlistCursor = j
+		m.showError(fmt.Sprintf("%s is already bookmarked", path))
+		return
+	}
+
+	m.inputDialog(fmt.Sprintf("Enter the name of the bookmark of %s:", path), func(value string, m *model) error {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return fmt.Errorf("Bookmark name cannot be empty")
+		}
+		m.bookmarks = m.bookmarks.Add(value, path)
+		m.hotlistCursor = len(m.bookmarks) - 1
+		m.saveBookmarks()
+		return nil
+	})
+	m.inputValue = vfs.Base(path)
+}
+
+func (m *model) renameBookmarkDialog(i int) {
+	m.inputDialog(fmt.Sprintf("Enter the new name of the bookmark of %s:", m.bookmarks[i].Path), func(value string, m *model) error {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return fmt.Errorf("Bookmark name cannot be empty")
+		}
+		m.bookmarks[i].Name = value
+		m.saveBookmarks()
+		return nil
+	})
+	m.inputValue = m.bookmarks[i].Name
+}
+
+// bookmarkKeyDialog changes the shortcut of a bookmark, swapping it with the
+// bookmark using it. An empty shortcut removes it.
+func (m *model) bookmarkKeyDialog(i int) {
+	m.inputDialog(fmt.Sprintf("Enter the shortcut of %s (a letter or a digit):", m.bookmarks[i].Name), func(value string, m *model) error {
+		key := strings.ToLower(strings.TrimSpace(value))
+		if key != "" && !bookmarks.ValidKey(key) {
+			return fmt.Errorf("Invalid shortcut %q", value)
+		}
+		if j, ok := m.bookmarks.FindKey(key); ok && key != "" {
+			m.bookmarks[j].Key = m.bookmarks[i].Key
+		}
+		m.bookmarks[i].Key = key
+		m.saveBookmarks()
+		return nil
+	})
+	m.inputValue = m.bookmarks[i].Key
+}
+
+// importBookmarks adds the GTK bookmarks and the $CDPATH directories missing
+// from the hotlist.
+func (m *model) importBookmarks() {
+	gtk, err := bookmarks.GTK()
+	if err != nil {
+		m.showError(fmt.Sprintf("Error reading the GTK bookmarks: %v", err))
+		return
+	}
+
+	var added int
+	m.bookmarks, added = m.bookmarks.Import(append(gtk, bookmarks.CDPath()...))
+	if added > 0 {
+		if err := bookmarks.Save(m.bookmarks); err != nil {
+			m.showError(fmt.Sprintf("Error saving the bookmarks: %v", err))
+			return
+		}
+	}
+	m.showError(fmt.Sprintf("Imported %d bookmarks", added))
+}
+
+func (m *model) renderHotlistDialog() string {
+	maxItems := max(1, m.windowHeight-14)
+	start := 0
+	if m.hotlistCursor >= maxItems {
+		start = m.hotlistCursor - maxItems + 1
+	}
+	end := min(len(m.bookmarks), start+maxItems)
+
+	nameWidth := 0
+	for _, b := range m.bookmarks {
+		nameWidth = max(nameWidth, lipgloss.Width(b.Name))
+	}
+	maxWidth := max(40, m.windowWidth-20)
+
+	lines := []string{}
+	for i := start; i < end; i++ {
+		b := m.bookmarks[i]
+		key := b.Key
+		if key == "" {
+			key = " "
+		}
+		line := ansi.Truncate(fmt.Sprintf("%s  %-*s  %s", key, nameWidth, b.Name, b.Path), maxWidth-2, "…")
+		if i == m.hotlistCursor {
+			line = activeButtonStyle.Padding(0, 1).MarginLeft(0).Render(line)
+		} else {
+			line = lipgloss.NewStyle().Padding(0, 1).Render(line)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		lines = append(lines, lipgloss.NewStyle().Padding(0, 1).Faint(true).Render("No bookmarks, + adds the directory of the panel"))
+	}
+
+	width := 40
+	for _, line := range lines {
+		width = max(width, lipgloss.Width(line))
+	}
+	title := lipgloss.NewStyle().Bold(true).MarginBottom(1).Render("Bookmarks")
+	help := lipgloss.NewStyle().Faint(true).MarginTop(1).Width(width).Render("enter/key: jump  +/-: add/remove  shift+up/down: move  ctrl+r: rename  ctrl+k: shortcut  ctrl+g: import GTK and CDPATH  esc: close")
+	ui := lipgloss.JoinVertical(lipgloss.Left, title, strings.Join(lines, "\n"), help)
+
+	return m.renderOverlayViews(dialogBoxStyle.Render(ui))
+}
@@ -0,0 +1,158 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/sandrolain/gommander/pkg/frecency"
+	"github.com/sandrolain/gommander/pkg/vfs"
+)
+
+// jumpLimit is the number of directories listed by the jump dialog.
+const jumpLimit = 50
+
+// SaveFrecency persists the directories visited by the model returned by the
+// program on exit.
+func SaveFrecency(tm tea.Model) error {
+	m, ok := tm.(model)
+	if !ok {
+		return nil
+	}
+	return m.frecency.Save()
+}
+
+func (m *model) jumpDialog() {
+	m.jumpOpen = true
+	m.jumpQuery = ""
+	m.updateJumpResults()
+}
+
+// updateJumpResults lists the directories matching the query by frecency,
+// except the one of the active panel and the local ones that are gone.
+func (m *model) updateJumpResults() {
+	current := m.panelDir(m.active)
+	m.jumpResults = nil
+	m.jumpCursor = 0
+	for _, e := range m.frecency.Query(m.jumpQuery, time.Now()) {
+		if e.Path == current {
+			continue
+		}
+		if vfs.IsLocal(e.Path) && checkDir(e.Path) != nil {
+			continue
+		}
+		m.jumpResults = append(m.jumpResults, e.Path)
+		if len(m.jumpResults) == jumpLimit {
+			break
+		}
+	}
+}
+
+func (m *model) updateJump(msg tea.KeyMsg, key string) tea.Cmd {
+	switch key {
+	case "up":
+		m.jumpCursor = max(0, m.jumpCursor-1)
+	case "down":
+		m.jumpCursor = max(0, min(len(m.jumpResults)-1, m.jumpCursor+1))
+	case KeyCancel:
+		m.jumpOpen = false
+	case KeyEnter:
+		if len(m.jumpResults) > 0 {
+			m.jumpOpen = false
+			if err := m.jumpTo(m.jumpResults[m.jumpCursor]); err != nil {
+				m.showError(err.Error())
+			}
+		}
+	case "backspace":
+		if len(m.jumpQuery) > 0 {
+			_, size := utf8.DecodeLastRuneInString(m.jumpQuery)
+			m.jumpQuery = m.jumpQuery[:len(m.jumpQuery)-size]
+			m.updateJumpResults()
+		}
+	case "delete":
+		// Forget the directory
+		if len(m.jumpResults) > 0 {
+			m.frecency.Remove(m.jumpResults[m.jumpCursor])
+			m.updateJumpResults()
+		}
+	case "ctrl+g":
+		m.importFrecency()
+	default:
+		if msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace {
+			m.jumpQuery += string(msg.Runes)
+			m.updateJumpResults()
+		}
+	}
+	return m.takePendingCmd()
+}
+
+// importFrecency adds the directories of the zoxide and autojump databases
+// found.
+func (m *model) importFrecency() {
+	now := time.Now()
+	sources := []struct {
+		name string
+		load func(time.Time) (int, error)
+	}{
+		{"zoxide", m.frecency.ImportZoxide},
+		{"autojump", m.frecency.ImportAutojump},
+	}
+
+	imported := []string{}
+	for _, source := range sources {
+		count, err := source.load(now)
+		if errors.Is(err, frecency.ErrNoDatabase) {
+			continue
+		}
+		if err != nil {
+			m.showError(fmt.Sprintf("Error importing from %s: %v", source.name, err))
+			return
+		}
+		imported = append(imported, fmt.Sprintf("%d directories from %s", count, source.name))
+	}
+	if len(imported) == 0 {
+		m.showError("No zoxide or autojump database found")
+		return
+	}
+
+	m.updateJumpResults()
+	m.showError("Imported " + strings.Join(imported, " and "))
+}
+
+func (m *model) renderJumpDialog() string {
+	width := min(max(60, m.windowWidth/2), m.windowWidth-20)
+	maxItems := max(1, m.windowHeight-16)
+	start := 0
+	if m.jumpCursor >= maxItems {
+		start = m.jumpCursor - maxItems + 1
+	}
+	end := min(len(m.jumpResults), start+maxItems)
+
+	itemStyle := lipgloss.NewStyle().Width(width).Padding(0, 1)
+	activeItemStyle := itemStyle.Foreground(themeColor(currentColors.ButtonFg)).Background(themeColor(currentColors.ButtonActiveBg)).Reverse(currentColors.ButtonActiveBg == "")
+
+	lines := []string{}
+	for i := start; i < end; i++ {
+		path := ansi.Truncate(m.jumpResults[i], width-2, "…")
+		if i == m.jumpCursor {
+			lines = append(lines, activeItemStyle.Render(path))
+		} else {
+			lines = append(lines, itemStyle.Render(path))
+		}
+	}
+	if len(lines) == 0 {
+		lines = append(lines, itemStyle.Faint(true).Render("No directories visited match"))
+	}
+
+	title := lipgloss.NewStyle().Width(width).Align(lipgloss.Center).Bold(true).MarginBottom(1).Render("Jump to")
+	input := inputStyle.Width(width).Render(m.jumpQuery + "▏")
+	help := lipgloss.NewStyle().Faint(true).MarginTop(1).Width(width).Render("type to match the path segments  enter: jump  delete: forget  ctrl+g: import zoxide/autojump  esc: close")
+	ui := lipgloss.JoinVertical(lipgloss.Left, title, input, strings.Join(lines, "\n"), help)
+
+	return m.renderOverlayViews(dialogBoxStyle.Render(ui))
+}
@@ -1,62 +1,58 @@
 package model
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"runtime"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/evertras/bubble-table/table"
+	"github.com/sandrolain/gommander/pkg/archive"
+	"github.com/sandrolain/gommander/pkg/bookmarks"
+	"github.com/sandrolain/gommander/pkg/config"
+	"github.com/sandrolain/gommander/pkg/dirsize"
+	"github.com/sandrolain/gommander/pkg/frecency"
 	"github.com/sandrolain/gommander/pkg/fs"
+	"github.com/sandrolain/gommander/pkg/opener"
+	"github.com/sandrolain/gommander/pkg/preview"
 	"github.com/sandrolain/gommander/pkg/rows"
-)
-
-var (
-	footLSty = lipgloss.NewStyle().
-			Foreground(lipgloss.Color(ColViolet))
-
-	footVSty = lipgloss.NewStyle().
-			Foreground(lipgloss.Color(ColOrange))
-
-	dialogBoxStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color(ColViolet)).
-			Padding(1, 2).
-			Margin(1, 1).
-			BorderTop(true).
-			BorderLeft(true).
-			BorderRight(true).
-			BorderBottom(true)
-
-	buttonStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color(ColLightYellow)).
-			Background(lipgloss.Color(ColDarkYellow)).
-			Padding(0, 3).
-			MarginLeft(1)
-
-	activeButtonStyle = buttonStyle.
-				Foreground(lipgloss.Color(ColLightYellow)).
-				Background(lipgloss.Color(ColPink)).
-				Padding(0, 3)
-
-	tableNormalStyle = lipgloss.NewStyle().
-				BorderForeground(lipgloss.Color(ColDarkGray)).Faint(true).
-				Align(lipgloss.Right)
-
-	tableActiveStyle = lipgloss.NewStyle().
-				BorderForeground(lipgloss.Color(ColWhite)).
-				Align(lipgloss.Right)
+	"github.com/sandrolain/gommander/pkg/vfs"
 )
 
 type model struct {
+	config             config.Config
+	rowsOptions        rows.Options
+	layout             layout
+	dirSizes           *dirsize.Calculator
+	du                 *diskUsage
+	dup                *duplicates
+	sync               *syncWizard
+	archiveJob         *archiveJob
+	connection         *connection
+	diff               *diffView
+	compareCancel      context.CancelFunc
+	compareSeq         int
+	sizeDirs           map[string]string // Directory of each panel whose sizes are computed
 	leftPanelDir       string
 	rightPanelDir      string
 	leftTable          table.Model
 	rightTable         table.Model
+	leftSort           rows.SortOptions
+	rightSort          rows.SortOptions
+	leftFilter         string
+	rightFilter        string
+	leftTabs           []tab
+	rightTabs          []tab
+	leftTab            int
+	rightTab           int
+	leftHistory        *history
+	rightHistory       *history
 	active             string
 	windowWidth        int
 	windowHeight       int
@@ -65,6 +61,14 @@ type model struct {
 	leftFilesInfo      rows.FilesInfo
 	rightFilesInfo     rows.FilesInfo
 	showHelp           bool
+	helpTop            int
+	tempDir            string // Copies of the opened remote files
+	showPreview        bool
+	previewFocused     bool
+	preview            preview.Preview
+	previewWidth       int
+	previewHeight      int
+	previewFrame       int
 	errorMessage       string
 	confirmMessage     string
 	confirmCallback    ConfirmCallback
@@ -72,6 +76,23 @@ type model struct {
 	inputMessage       string
 	inputCallback      InputCallback
 	inputValue         string // Nome della nuova directory
+	menuTitle          string
+	menuItems          []string
+	menuCursor         int
+	menuCallback       MenuCallback
+	columnsPanel       string
+	columnsItems       []columnItem
+	columnsCursor      int
+	bookmarks          bookmarks.List
+	hotlistOpen        bool
+	hotlistCursor      int
+	frecency           *frecency.DB
+	jumpOpen           bool
+	jumpQuery          string
+	jumpCursor         int
+	jumpResults        []string
+	pendingCmd         tea.Cmd
+	openDefaults       opener.Defaults
 	view               string
 	key                string
 	log                string
@@ -81,19 +102,40 @@ type model struct {
 
 type UpdateWatcherFn func(string, func()) error
 
-func InitialModel(ul UpdateWatcherFn, ur UpdateWatcherFn) model {
+type execFinishedMsg struct {
+	err error
+}
+
+func InitialModel(cfg config.Config, ul UpdateWatcherFn, ur UpdateWatcherFn) model {
 	currentDir, _ := os.Getwd()
 
-	leftFilesInfo, leftTable := createTable(currentDir)
-	rightFilesInfo, rightTable := createTable(currentDir)
+	applyKeys(cfg.Keys)
+	vfs.SetRemotes(cfg.Remotes)
+	themeErr := applyTheme(cfg.Theme)
+
+	panelsLayout, layoutErr := loadLayout(cfg)
+	dirSizes := dirsize.New(cfg.DirSize.Workers)
+	rowsOptions := panelRowsOptions(cfg, rows.Options{Sort: cfg.Sort, Format: cfg.Format, DirSizes: dirSizes.Cached}, panelsLayout)
+
+	leftFilesInfo, leftTable := createTable(currentDir, panelsLayout.Left, rowsOptions)
+	rightFilesInfo, rightTable := createTable(currentDir, panelsLayout.Right, rowsOptions)
 
 	leftTable = leftTable.Focused(true)
 
 	m := model{
+		config:             cfg,
+		rowsOptions:        rowsOptions,
+		layout:             panelsLayout,
+		dirSizes:           dirSizes,
+		sizeDirs:           map[string]string{},
 		leftPanelDir:       currentDir,
 		rightPanelDir:      currentDir,
 		leftTable:          leftTable,
 		rightTable:         rightTable,
+		leftSort:           cfg.Sort,
+		rightSort:          cfg.Sort,
+		leftTabs:           []tab{{}},
+		rightTabs:          []tab{{}},
 		active:             "left",
 		windowWidth:        0,
 		windowHeight:       0,
@@ -105,8 +147,31 @@ func InitialModel(ul UpdateWatcherFn, ur UpdateWatcherFn) model {
 		updateRightWatcher: ur,
 	}
 
+	if themeErr != nil {
+		m.showError(themeErr.Error())
+	}
+
+	if layoutErr != nil {
+		m.log = fmt.Sprintf("Error loading the layout: %s", layoutErr)
+	}
+
 	var err error
 
+	m.openDefaults, err = opener.LoadDefaults()
+	if err != nil {
+		m.log = fmt.Sprintf("Error loading default openers: %s", err)
+	}
+
+	m.bookmarks, err = bookmarks.Load()
+	if err != nil {
+		m.log = fmt.Sprintf("Error loading the bookmarks: %s", err)
+	}
+
+	m.frecency, err = frecency.Load()
+	if err != nil {
+		m.log = fmt.Sprintf("Error loading the visited directories: %s", err)
+	}
+
 	err = m.updateLeftWatcher(currentDir, func() {
 		m.refreshLeftTableRows()
 	})
@@ -121,47 +186,34 @@ func InitialModel(ul UpdateWatcherFn, ur UpdateWatcherFn) model {
 		m.log = fmt.Sprintf("Error creating right watcher: %s", err)
 	}
 
+	m.autoDirSizes("left")
+	m.autoDirSizes("right")
+
+	if err := m.restoreTabs(); err != nil {
+		m.log = fmt.Sprintf("Error restoring the tabs: %s", err)
+	}
+
 	return m
 }
 
-func createTable(dir string) (rows.FilesInfo, table.Model) {
-	filesInfo, rows := rows.GetTableRows(dir)
-
-	nameCol := table.NewFlexColumn("name", "Name", 10)
-	nameCol = nameCol.WithStyle(nameCol.Style().Align(lipgloss.Left))
+func createTable(dir string, columns []config.Column, opts rows.Options) (rows.FilesInfo, table.Model) {
+	filesInfo, rows := rows.GetTableRows(dir, opts)
 
 	km := table.DefaultKeyMap()
-	km.RowSelectToggle.SetKeys(" ")
-
-	t := table.New([]table.Column{
-		nameCol,
-		table.NewColumn("size", "Size", 8),
-		table.NewColumn("mode", "Mode", 10).WithStyle(lipgloss.NewStyle().Align(lipgloss.Center)),
-		table.NewColumn("modified", "Modified", 19).WithStyle(lipgloss.NewStyle().Align(lipgloss.Center)),
-	}).WithRows(rows).
+	km.RowSelectToggle.SetKeys(tableKey(KeySelect))
+
+	t := table.New(tableColumns(columns)).WithRows(rows).
 		BorderRounded().
 		SelectableRows(true).
-		WithRowStyleFunc(func(rsfi table.RowStyleFuncInput) lipgloss.Style {
-			if rsfi.IsHighlighted {
-				return lipgloss.NewStyle().Bold(true).Background(lipgloss.Color(ColViolet)).Foreground(lipgloss.Color(ColWhite))
-			}
-
-			if rsfi.Row.Data["name"] == ".." {
-				return lipgloss.NewStyle().Foreground(lipgloss.Color(ColDarkYellow))
-			}
-
-			if rsfi.Row.Data["dir"] == true {
-				return lipgloss.NewStyle().Foreground(lipgloss.Color(ColOrange))
-			}
-
-			return lipgloss.NewStyle().Foreground(lipgloss.Color(ColLightBlue))
-		}).WithKeyMap(km).HeaderStyle(lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(ColPink)))
+		WithRowStyleFunc(rowStyle).
+		WithKeyMap(km).
+		HeaderStyle(tableHeaderStyle)
 
 	return filesInfo, t
 }
 
 func (m model) Init() tea.Cmd {
-	return nil
+	return waitDirSize(m.dirSizes)
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -170,6 +222,30 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.updateTablesWidth(msg)
 	case tea.FocusMsg:
 		m.refreshTablesRows(true, true)
+		m.preview = preview.Preview{}
+	case duProgressMsg, duDoneMsg:
+		return m, m.updateDiskUsageScan(msg)
+	case dupProgressMsg, dupDoneMsg:
+		return m, m.updateDuplicatesScan(msg)
+	case compareMsg:
+		m.updateCompare(msg)
+	case syncPlanMsg:
+		m.updateSyncPlan(msg)
+	case syncProgressMsg, syncDoneMsg:
+		return m, m.updateSyncRun(msg)
+	case archiveProgressMsg, archiveDoneMsg:
+		return m, m.updateArchiveJob(msg)
+	case connectedMsg:
+		m.updateConnected(msg)
+	case dirSizeMsg:
+		m.updateDirSize(msg)
+		return m, waitDirSize(m.dirSizes)
+	case execFinishedMsg:
+		if msg.err != nil {
+			m.showError(fmt.Sprintf("Error running command: %v", msg.err))
+		}
+		m.refreshTablesRows(true, true)
+		m.preview = preview.Preview{}
 	case tea.MouseMsg:
 		change := 0
 		if msg.Button == tea.MouseButtonWheelDown {
@@ -187,31 +263,51 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	case tea.KeyMsg:
 		key := msg.String()
+		if key == " " {
+			key = "space"
+		}
 
 		m.key = key
 
 		if m.showHelp {
-			if key == KeyEnter || key == KeyCancel {
+			switch key {
+			case KeyEnter, KeyCancel:
 				m.showHelp = false
-				return m, nil
+				m.helpTop = 0
+			case "up", "k":
+				m.helpTop--
+			case "down", "j":
+				m.helpTop++
+			case "pgup":
+				m.helpTop -= m.helpPageSize()
+			case "pgdown":
+				m.helpTop += m.helpPageSize()
+			case "home":
+				m.helpTop = 0
+			case "end":
+				m.helpTop = len(keyBindings)
 			}
+			m.helpTop = max(0, min(m.helpTop, len(keyBindings)-m.helpPageSize()))
 			return m, nil
 		}
 
 		if m.inputMessage != "" {
 
 			if key == "enter" {
-				if m.inputCallback != nil {
-					err := m.inputCallback(m.inputValue, &m)
+				// Clear the dialog first, the callback may open a new one
+				callback := m.inputCallback
+				value := m.inputValue
+				m.inputCallback = nil
+				m.inputMessage = ""
+				m.inputValue = ""
+				if callback != nil {
+					err := callback(value, &m)
 					if err != nil {
 						m.showError(err.Error())
 					}
 					m.refreshTablesRows(true, false)
 				}
-				m.inputCallback = nil
-				m.inputMessage = ""
-				m.inputValue = ""
-				return m, nil
+				return m, m.takePendingCmd()
 			}
 
 			if key == "esc" {
@@ -223,12 +319,15 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 			if key == "backspace" {
 				if len(m.inputValue) > 0 {
-					m.inputValue = m.inputValue[:len(m.inputValue)-1]
+					_, size := utf8.DecodeLastRuneInString(m.inputValue)
+					m.inputValue = m.inputValue[:len(m.inputValue)-size]
 				}
 				return m, nil
 			}
 
-			m.inputValue += key
+			if msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace {
+				m.inputValue += string(msg.Runes)
+			}
 			return m, nil
 		}
 
@@ -247,21 +346,22 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 
 				if m.confirmCallback != nil {
-					if m.confirmBtn == 0 {
-						m.confirmMessage = ""
-						m.confirmCallback = nil
+					callback := m.confirmCallback
+					confirmed := m.confirmBtn == 1
+					m.confirmBtn = 0
+					m.confirmCallback = nil
+					m.confirmMessage = ""
+
+					if !confirmed {
 						return m, nil
 					}
 
-					err := m.confirmCallback(&m)
+					err := callback(&m)
 					if err != nil {
 						m.showError(err.Error())
 					}
-					m.confirmBtn = 0
-					m.confirmCallback = nil
-					m.confirmMessage = ""
 
-					return m, nil
+					return m, m.takePendingCmd()
 				}
 
 			case KeyCancel:
@@ -281,6 +381,74 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
+		if m.columnsPanel != "" {
+			m.updateColumnsDialog(key)
+			return m, nil
+		}
+
+		if m.hotlistOpen {
+			return m, m.updateHotlist(key)
+		}
+
+		if m.jumpOpen {
+			return m, m.updateJump(msg, key)
+		}
+
+		if m.menuTitle != "" {
+			switch key {
+			case "up", "k":
+				m.menuCursor = max(0, m.menuCursor-1)
+			case "down", "j":
+				m.menuCursor = min(len(m.menuItems)-1, m.menuCursor+1)
+			case "home":
+				m.menuCursor = 0
+			case "end":
+				m.menuCursor = len(m.menuItems) - 1
+			case KeyEnter:
+				callback := m.menuCallback
+				index := m.menuCursor
+				count := len(m.menuItems)
+				m.closeMenu()
+				if callback != nil && index < count {
+					err := callback(index, &m)
+					if err != nil {
+						m.showError(err.Error())
+					}
+				}
+				return m, m.takePendingCmd()
+			case KeyCancel:
+				m.closeMenu()
+			}
+			return m, nil
+		}
+
+		if m.showPreview && m.previewFocused {
+			if key == KeyPFocus || key == KeyCancel || key == KeyPreview {
+				m.previewFocused = false
+			} else if key == KeyQuit {
+				return m, tea.Quit
+			} else {
+				m.preview.Update(key)
+			}
+			return m, nil
+		}
+
+		if m.diff != nil {
+			return m, m.updateDiff(key)
+		}
+
+		if m.du != nil {
+			return m, m.updateDiskUsage(key)
+		}
+
+		if m.dup != nil {
+			return m, m.updateDuplicates(key)
+		}
+
+		if m.sync != nil {
+			return m, m.updateSync(key)
+		}
+
 		switch key {
 		case KeyQuit:
 			return m, tea.Quit
@@ -324,10 +492,13 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 
-			err = m.enterFile(newPath)
+			cmd, err := m.enterFile(newPath)
 			if err != nil {
 				m.showError(err.Error())
 			}
+			if cmd != nil {
+				return m, cmd
+			}
 
 		case KeyBack:
 
@@ -338,22 +509,32 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				currentPath = m.rightPanelDir
 			}
 
-			newPath := filepath.Join(currentPath, "..")
+			newPath := vfs.Dir(currentPath)
 
-			err := m.enterFile(newPath)
+			_, err := m.enterFile(newPath)
 			if err != nil {
 				m.showError(err.Error())
+				return m, nil
 			}
 
+			// Highlight the directory left
+			currentTable := m.getTable()
+			*currentTable = currentTable.WithHighlightedRow(rowIndex(*currentTable, vfs.Base(currentPath)))
+
 		case KeyCopy:
 
+			destPath, _ := m.getDestinationDirPath()
+			if m.refuseReadOnly(destPath) {
+				return m, nil
+			}
+
 			paths, err := m.getCurrentRowsPaths()
 			if err != nil {
 				m.showError("Error getting paths to copy")
 				return m, nil
 			}
 
-			m.confirmDialog(fmt.Sprintf("Are you sure you want to copy\n%s?", strings.Join(paths, "\n")), func(m *model) error {
+			m.confirmAction(m.config.Confirm.Copy, fmt.Sprintf("Are you sure you want to copy\n%s?", strings.Join(paths, "\n")), func(m *model) error {
 				err := m.copyFiles(false)
 				if err != nil {
 					return fmt.Errorf("Error copying file: %v", err)
@@ -364,13 +545,18 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		case KeyCopyO:
 
+			destPath, _ := m.getDestinationDirPath()
+			if m.refuseReadOnly(destPath) {
+				return m, nil
+			}
+
 			paths, err := m.getCurrentRowsPaths()
 			if err != nil {
 				m.showError("Error getting paths to copy")
 				return m, nil
 			}
 
-			m.confirmDialog(fmt.Sprintf("Are you sure you want to copy with overwrite\n%s?", strings.Join(paths, "\n")), func(m *model) error {
+			m.confirmAction(m.config.Confirm.Copy, fmt.Sprintf("Are you sure you want to copy with overwrite\n%s?", strings.Join(paths, "\n")), func(m *model) error {
 				err := m.copyFiles(true)
 				if err != nil {
 					return fmt.Errorf("Error copying file: %v", err)
@@ -379,16 +565,38 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return nil
 			})
 
-		case KeyMove:
+		case KeyMove, KeyMoveO:
 
-			err := m.moveFiles(false)
+			srcPath, _ := m.getSourceDirPath()
+			destPath, _ := m.getDestinationDirPath()
+			if m.refuseReadOnly(srcPath, destPath) {
+				return m, nil
+			}
+
+			paths, err := m.getCurrentRowsPaths()
 			if err != nil {
-				m.showError(err.Error())
+				m.showError("Error getting paths to move")
+				return m, nil
+			}
+
+			overwrite := key == KeyMoveO
+			text := "Are you sure you want to move\n%s?"
+			if overwrite {
+				text = "Are you sure you want to move with overwrite\n%s?"
 			}
 
-			m.refreshTablesRows(true, true)
+			m.confirmAction(m.config.Confirm.Move, fmt.Sprintf(text, strings.Join(paths, "\n")), func(m *model) error {
+				err := m.moveFiles(overwrite)
+				m.refreshTablesRows(true, true)
+				return err
+			})
+
+		case KeyDelete:
 
-		case "ctrl+d":
+			srcPath, _ := m.getSourceDirPath()
+			if m.refuseReadOnly(srcPath) {
+				return m, nil
+			}
 
 			paths, err := m.getCurrentRowsPaths()
 			if err != nil {
@@ -396,25 +604,29 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 
-			m.confirmMessage = fmt.Sprintf("Are you sure you want to delete\n%s?", strings.Join(paths, "\n"))
-			m.confirmCallback = func(m *model) error {
-				err := fs.DeleteFiles(paths)
+			m.confirmAction(m.config.Confirm.Delete, fmt.Sprintf("Are you sure you want to delete\n%s?", strings.Join(paths, "\n")), func(m *model) error {
+				err := vfs.Delete(paths)
 				if err != nil {
 					return fmt.Errorf("Error deleting file: %v", err)
 				}
 				m.refreshTablesRows(true, false)
 				return nil
-			}
+			})
 
 		case KeyTrash:
 
+			srcPath, _ := m.getSourceDirPath()
+			if m.refuseRemote(srcPath) {
+				return m, nil
+			}
+
 			paths, err := m.getCurrentRowsPaths()
 			if err != nil {
 				m.showError(fmt.Sprintf("Error getting paths: %v", err))
 				return m, nil
 			}
 
-			m.confirmDialog(fmt.Sprintf("Are you sure you want move to trash\n%s?", strings.Join(paths, "\n")), func(m *model) error {
+			m.confirmAction(m.config.Confirm.Trash, fmt.Sprintf("Are you sure you want move to trash\n%s?", strings.Join(paths, "\n")), func(m *model) error {
 				err := fs.TrashFiles(paths)
 				if err != nil {
 					return fmt.Errorf("Error moving to trash file: %v", err)
@@ -425,6 +637,11 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		case KeyMkdir:
 
+			srcPath, _ := m.getSourceDirPath()
+			if m.refuseReadOnly(srcPath) {
+				return m, nil
+			}
+
 			m.inputDialog("Enter the name of the new directory:", func(value string, m *model) error {
 				if value == "" {
 					return fmt.Errorf("Directory name cannot be empty")
@@ -439,6 +656,11 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		case KeyMkfile:
 
+			srcPath, _ := m.getSourceDirPath()
+			if m.refuseReadOnly(srcPath) {
+				return m, nil
+			}
+
 			m.inputDialog("Enter the name of the new file:", func(value string, m *model) error {
 				if value == "" {
 					return fmt.Errorf("File name cannot be empty")
@@ -451,26 +673,167 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return nil
 			})
 
-		case KeyVscode:
+		case KeyEditor:
 
-			err := m.openVsCode()
+			srcPath, _ := m.getSourceDirPath()
+			if m.refuseRemote(srcPath) {
+				return m, nil
+			}
+
+			cmd, err := m.openEditor()
 			if err != nil {
 				m.showError(err.Error())
 			}
+			if cmd != nil {
+				return m, cmd
+			}
+
+		case KeyOpenWith:
+
+			srcPath, _ := m.getSourceDirPath()
+			if m.refuseRemote(srcPath) {
+				return m, nil
+			}
+
+			err := m.openWith()
+			if err != nil {
+				m.showError(err.Error())
+			}
+
+		case KeySort:
+
+			m.sortDialog()
+
+		case KeyTheme:
+
+			m.themeDialog()
+
+		case KeyColumns:
+
+			m.columnsDialog()
+
+		case KeyFormat:
+
+			m.cycleTimeFormat()
+
+		case KeySizeFormat:
+
+			m.cycleSizeFormat()
+
+		case KeyDirSize:
+
+			m.computeDirSizes()
+
+		case KeyDiskUsage:
+
+			return m, m.startDiskUsage()
+
+		case KeyDuplicates:
+
+			return m, m.startDuplicates()
+
+		case KeyCompare:
+
+			m.compareDialog()
+
+		case KeySync:
+
+			m.syncDialog()
+
+		case KeyDiff:
+
+			m.startDiff()
+
+		case KeyPack:
+
+			m.packDialog()
+
+		case KeyExtract:
+
+			m.extractDialog()
+			return m, m.takePendingCmd()
+
+		case KeyConnect:
+
+			m.connectDialog()
+
+		case KeyNewTab:
+
+			if err := m.newTab(); err != nil {
+				m.showError(err.Error())
+			}
+
+		case KeyCloseTab:
+
+			if err := m.closeTab(); err != nil {
+				m.showError(err.Error())
+			}
+
+		case KeyNextTab, KeyPrevTab:
+
+			delta := 1
+			if key == KeyPrevTab {
+				delta = -1
+			}
+			if err := m.cycleTab(delta); err != nil {
+				m.showError(err.Error())
+			}
+
+		case KeyFilter:
+
+			m.filterDialog()
+
+		case KeyHistBack, KeyHistNext:
+
+			if err := m.moveHistory(key == KeyHistNext); err != nil {
+				m.showError(err.Error())
+			}
+
+		case KeyHistory:
+
+			m.historyDialog()
+
+		case KeyBookmarks:
+
+			m.hotlistDialog()
+
+		case KeyJump:
+
+			m.jumpDialog()
+
+		case KeyCancel:
+
+			m.cancelDirSizes()
+			m.cancelComparison()
+			m.cancelArchiveJob()
+			m.cancelConnection()
+
+		case KeyPreview:
+
+			m.showPreview = !m.showPreview
+			m.previewFocused = false
+			m.preview = preview.Preview{}
+
+		case KeyPFocus:
+
+			if m.showPreview {
+				m.previewFocused = true
+			}
 
 		}
 
+		var cmd tea.Cmd
 		if m.active == "left" {
-			var cmd tea.Cmd
 			m.leftTable, cmd = m.leftTable.Update(msg)
-			return m, cmd
+		} else {
+			m.rightTable, cmd = m.rightTable.Update(msg)
 		}
 
-		var cmd tea.Cmd
-		m.rightTable, cmd = m.rightTable.Update(msg)
+		m.updatePreview()
 		return m, cmd
 	}
 
+	m.updatePreview()
 	return m, nil
 }
 
@@ -511,6 +874,16 @@ func (m *model) getCurrentRowsPaths() ([]string, error) {
 	return nil, fmt.Errorf("no path selected")
 }
 
+func (m *model) getSourceDirPath() (string, error) {
+	var srcPath string
+	if m.active == "left" {
+		srcPath = m.leftPanelDir
+	} else {
+		srcPath = m.rightPanelDir
+	}
+	return srcPath, nil
+}
+
 func (m *model) getDestinationDirPath() (string, error) {
 	var destPath string
 	if m.active == "left" {
@@ -521,68 +894,141 @@ func (m *model) getDestinationDirPath() (string, error) {
 	return destPath, nil
 }
 
-func (m *model) enterFile(path string) error {
-	info, err := os.Stat(path)
+func (m *model) enterFile(path string) (tea.Cmd, error) {
+	fsys, fpath, err := vfs.Resolve(path)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	info, err := fsys.Stat(fpath)
+	if err != nil {
+		return nil, err
 	}
 
-	if info.IsDir() {
-		filesInfo, newRows := rows.GetTableRows(path)
-		// Enter directory
-		if m.active == "left" {
-			err := m.updateLeftWatcher(path, func() {
+	// The archives are shown as read-only directories
+	isArchive := false
+	if _, ok := fsys.(vfs.Local); ok && info.Mode().IsRegular() && archive.Supported(path) {
+		if _, err := archive.Open(path); err != nil {
+			return nil, err
+		}
+		isArchive = true
+	}
+
+	if info.IsDir() || isArchive {
+		from := m.panelDir(m.active)
+		if err := m.enterDir(path, watchDir(path)); err != nil {
+			return nil, err
+		}
+		m.panelHistory(m.active).visit(from, path)
+		m.frecency.Visit(path, time.Now())
+		return nil, nil
+	}
+
+	if _, ok := fsys.(vfs.Local); !ok {
+		return m.openRemoteFile(path)
+	}
+	return m.openFile(path)
+}
+
+// enterDir shows the directory in the active panel, watching watchDir for
+// changes when set. The row highlighted in the directory left is remembered
+// and the one of the directory shown restored.
+func (m *model) enterDir(path string, watchDir string) error {
+	from := m.panelDir(m.active)
+	h := m.panelHistory(m.active)
+	if name, ok := m.getTable().HighlightedRow().Data["name"].(string); ok && from != "" {
+		h.cursors[from] = name
+	}
+
+	filesInfo, newRows := rows.GetTableRows(path, m.panelOptions(m.active))
+	if m.active == "left" {
+		if watchDir != "" {
+			err := m.updateLeftWatcher(watchDir, func() {
 				m.refreshLeftTableRows()
 			})
 			if err != nil {
 				return fmt.Errorf("error creating watcher: %v", err)
 			}
-			m.leftPanelDir = path
-			m.leftTable = m.leftTable.WithRows(newRows).WithHighlightedRow(0).WithAllRowsDeselected()
-			m.leftFilesInfo = filesInfo
-		} else {
-			err := m.updateRightWatcher(path, func() {
+		}
+		m.leftPanelDir = path
+		m.leftTable = m.leftTable.WithRows(newRows).WithAllRowsDeselected()
+		m.leftTable = m.leftTable.WithHighlightedRow(rowIndex(m.leftTable, h.cursors[path]))
+		m.leftFilesInfo = filesInfo
+		m.autoDirSizes("left")
+	} else {
+		if watchDir != "" {
+			err := m.updateRightWatcher(watchDir, func() {
 				m.refreshRightTableRows()
 			})
 			if err != nil {
 				return fmt.Errorf("error creating watcher: %v", err)
 			}
-			m.rightPanelDir = path
-			m.rightTable = m.rightTable.WithRows(newRows).WithHighlightedRow(0).WithAllRowsDeselected()
-			m.rightFilesInfo = filesInfo
 		}
-
-		return nil
+		m.rightPanelDir = path
+		m.rightTable = m.rightTable.WithRows(newRows).WithAllRowsDeselected()
+		m.rightTable = m.rightTable.WithHighlightedRow(rowIndex(m.rightTable, h.cursors[path]))
+		m.rightFilesInfo = filesInfo
+		m.autoDirSizes("right")
+	}
+	if from != "" {
+		h.shown(from)
 	}
+	h.shown(path)
+	return nil
+}
 
-	// If it's a file, open it with the default application
-	var cmd *exec.Cmd
-	switch runtime.GOOS {
-	case "windows":
-		cmd = exec.Command("cmd", "/c", "start", path)
-	case "darwin":
-		cmd = exec.Command("open", path)
-	default: // Assume Linux or other Unix-like OS
-		cmd = exec.Command("xdg-open", path)
+// openFile opens the file with the application remembered for its type,
+// the first matching opener rule, or the default application of the
+// operating system. Terminal commands suspend
+// the program until they exit.
+func (m *model) openFile(path string) (tea.Cmd, error) {
+	if choice, ok := m.openDefaults.Find(path); ok {
+		cmd, err := choice.Cmd(path)
+		if err != nil {
+			return nil, fmt.Errorf("error opening file: %v", err)
+		}
+		return runCommand(cmd, choice.Terminal)
 	}
 
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("error opening file: %v", err)
+	rule, ok := opener.Find(m.config.Openers, path)
+	if !ok {
+		if err := opener.Default(path).Start(); err != nil {
+			return nil, fmt.Errorf("error opening file: %v", err)
+		}
+		return nil, nil
 	}
 
-	return nil
+	cmd, err := rule.Cmd(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file: %v", err)
+	}
+	return runCommand(cmd, rule.Terminal)
 }
 
-func (m *model) openVsCode() error {
+func (m *model) openEditor() (tea.Cmd, error) {
 	path, err := m.getHighlightedRowPath(false)
 	if err != nil {
-		return fmt.Errorf("error getting highlighted row path: %v", err)
+		return nil, fmt.Errorf("error getting highlighted row path: %v", err)
+	}
+
+	editor := opener.ResolveEditor(m.config.Editor)
+	cmd, err := editor.Cmd(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening editor: %v", err)
 	}
-	cmd := exec.Command("code", path)
+	return runCommand(cmd, editor.Terminal)
+}
+
+func runCommand(cmd *exec.Cmd, terminal bool) (tea.Cmd, error) {
+	if terminal {
+		return tea.ExecProcess(cmd, func(err error) tea.Msg {
+			return execFinishedMsg{err: err}
+		}), nil
+	}
+
 	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("error opening VS Code: %v", err)
+		return nil, fmt.Errorf("error running %s: %v", cmd.Path, err)
 	}
-	return nil
+	return nil, nil
 }
 
 func (m *model) refreshTablesRows(source bool, dest bool) {
@@ -591,27 +1037,74 @@ func (m *model) refreshTablesRows(source bool, dest bool) {
 	right := (m.active == "right" && source) || (m.active == "left" && dest)
 
 	if left || (right && same) {
+		m.dirSizes.Invalidate(m.leftPanelDir)
 		m.refreshLeftTableRows()
+		m.autoDirSizes("left")
 	}
 	if right || (left && same) {
+		m.dirSizes.Invalidate(m.rightPanelDir)
 		m.refreshRightTableRows()
+		m.autoDirSizes("right")
 	}
 }
 
 func (m *model) refreshLeftTableRows() {
 	path := m.leftPanelDir
-	filesInfo, newRows := rows.GetTableRows(path)
-	m.leftTable = m.leftTable.WithRows(newRows).WithHighlightedRow(0)
+	filesInfo, newRows := rows.GetTableRows(path, m.panelOptions("left"))
+	m.leftTable = refreshTable(m.leftTable, newRows)
+	m.leftTable = m.leftTable.WithColumns(m.compareColumns("left"))
 	m.leftFilesInfo = filesInfo
 }
 
 func (m *model) refreshRightTableRows() {
 	path := m.rightPanelDir
-	filesInfo, newRows := rows.GetTableRows(path)
-	m.rightTable = m.rightTable.WithRows(newRows).WithHighlightedRow(0)
+	filesInfo, newRows := rows.GetTableRows(path, m.panelOptions("right"))
+	m.rightTable = refreshTable(m.rightTable, newRows)
+	m.rightTable = m.rightTable.WithColumns(m.compareColumns("right"))
 	m.rightFilesInfo = filesInfo
 }
 
+// refreshTable replaces the rows of the table keeping the selection of the
+// entries still listed and the highlighted entry, or the nearest one still
+// listed when it is gone. The page follows the highlighted row.
+func refreshTable(t table.Model, newRows []table.Row) table.Model {
+	oldRows := t.GetVisibleRows()
+	cursor := t.GetHighlightedRowIndex()
+	selected := map[interface{}]bool{}
+	for _, row := range t.SelectedRows() {
+		selected[row.Data["path"]] = true
+	}
+
+	listed := map[interface{}]bool{}
+	for i, row := range newRows {
+		listed[row.Data["path"]] = true
+		if selected[row.Data["path"]] {
+			newRows[i] = row.Selected(true)
+		}
+	}
+
+	// The entries after the highlighted one first, then the ones before
+	highlighted := interface{}(nil)
+	for i := cursor; i < len(oldRows) && highlighted == nil; i++ {
+		if listed[oldRows[i].Data["path"]] {
+			highlighted = oldRows[i].Data["path"]
+		}
+	}
+	for i := min(cursor, len(oldRows)) - 1; i >= 0 && highlighted == nil; i-- {
+		if listed[oldRows[i].Data["path"]] {
+			highlighted = oldRows[i].Data["path"]
+		}
+	}
+
+	t = t.WithRows(newRows)
+	for i, row := range t.GetVisibleRows() {
+		if row.Data["path"] == highlighted {
+			return t.WithHighlightedRow(i)
+		}
+	}
+	return t.WithHighlightedRow(cursor)
+}
+
 func (m *model) showError(msg string) {
 	m.errorMessage = msg
 }
@@ -620,8 +1113,27 @@ func (m *model) updateTablesWidth(msg tea.WindowSizeMsg) {
 	m.windowWidth = msg.Width
 	m.windowHeight = msg.Height
 	m.panelWidth = m.windowWidth / 2
-	m.leftTable = m.leftTable.WithTargetWidth(m.panelWidth).WithMinimumHeight(m.windowHeight).WithPageSize(m.windowHeight - extraRows)
-	m.rightTable = m.rightTable.WithTargetWidth(m.panelWidth).WithMinimumHeight(m.windowHeight).WithPageSize(m.windowHeight - extraRows)
+	m.previewWidth = m.panelWidth - previewStyle.GetHorizontalFrameSize()
+	m.previewHeight = m.windowHeight - previewStyle.GetVerticalFrameSize()
+	m.leftTable = m.sizeTable(m.leftTable)
+	m.rightTable = m.sizeTable(m.rightTable)
+
+	// The pages of the views replacing the preview change with the height
+	if m.du != nil && !m.du.scanning {
+		m.du.scroll(m.duPageSize())
+	}
+	if m.dup != nil && !m.dup.scanning {
+		m.dup.scroll(m.dupPageSize())
+	}
+	if m.sync != nil && !m.sync.planning {
+		m.sync.scroll(m.syncPageSize())
+	}
+}
+
+// sizeTable fits the table in a panel, below the tab bar.
+func (m *model) sizeTable(t table.Model) table.Model {
+	height := m.windowHeight - tabBarRows
+	return t.WithTargetWidth(m.panelWidth).WithMinimumHeight(height).WithPageSize(height - extraRows)
 }
 
 func fL(faint bool, s string) string {
@@ -648,7 +1160,8 @@ func (m model) View() string {
 		fL(leftFaint, "Total: ")+
 			fV(leftFaint, fmt.Sprintf("%d", m.leftFilesInfo.Total))+fL(leftFaint, " | Dirs: ")+
 			fV(leftFaint, fmt.Sprintf("%d", m.leftFilesInfo.Dirs))+fL(leftFaint, " | Files: ")+
-			fV(leftFaint, fmt.Sprintf("%d", m.leftFilesInfo.Files))+fL(leftFaint, " - ")+fL(leftFaint, fmt.Sprintf("%d/%d", m.leftTable.CurrentPage(), m.leftTable.MaxPages())),
+			fV(leftFaint, fmt.Sprintf("%d", m.leftFilesInfo.Files))+fL(leftFaint, " - ")+fL(leftFaint, fmt.Sprintf("%d/%d", m.leftTable.CurrentPage(), m.leftTable.MaxPages()))+
+			fL(leftFaint, m.dirSizesStatus("left")+m.compareStatus(m.leftTable)+m.archiveJobStatus("left")+m.connectionStatus("left")),
 	)
 
 	rightFooter := lipgloss.JoinVertical(
@@ -657,7 +1170,8 @@ func (m model) View() string {
 		fL(rightFaint, "Total: ")+
 			fV(rightFaint, fmt.Sprintf("%d", m.rightFilesInfo.Total))+fL(rightFaint, " | Dirs: ")+
 			fV(rightFaint, fmt.Sprintf("%d", m.rightFilesInfo.Dirs))+fL(rightFaint, " | Files: ")+
-			fV(rightFaint, fmt.Sprintf("%d", m.rightFilesInfo.Files))+fL(rightFaint, " - ")+fL(rightFaint, fmt.Sprintf("%d/%d", m.rightTable.CurrentPage(), m.rightTable.MaxPages())),
+			fV(rightFaint, fmt.Sprintf("%d", m.rightFilesInfo.Files))+fL(rightFaint, " - ")+fL(rightFaint, fmt.Sprintf("%d/%d", m.rightTable.CurrentPage(), m.rightTable.MaxPages()))+
+			fL(rightFaint, m.dirSizesStatus("right")+m.compareStatus(m.rightTable)+m.archiveJobStatus("right")+m.connectionStatus("right")),
 	)
 
 	//	leftTable := m.leftTable.WithStaticFooter(fL(leftFaint, m.log+" - "+m.key+" - ") + leftFooter)
@@ -672,10 +1186,45 @@ func (m model) View() string {
 		rightTable = rightTable.WithBaseStyle(tableActiveStyle)
 	}
 
-	leftContent := leftTable.View()
-	rightContent := rightTable.View()
+	leftContent := lipgloss.JoinVertical(lipgloss.Left, m.tabBar("left"), leftTable.View())
+	rightContent := lipgloss.JoinVertical(lipgloss.Left, m.tabBar("right"), rightTable.View())
+
+	if m.showPreview {
+		if m.active == "left" {
+			rightContent = m.renderPreview()
+		} else {
+			leftContent = m.renderPreview()
+		}
+	}
+
+	if m.du != nil {
+		if m.du.panel == "left" {
+			leftContent = m.renderDiskUsage()
+		} else {
+			rightContent = m.renderDiskUsage()
+		}
+	}
+
+	if m.dup != nil {
+		if m.dup.panel == "left" {
+			leftContent = m.renderDuplicates()
+		} else {
+			rightContent = m.renderDuplicates()
+		}
+	}
+
+	if m.sync != nil {
+		if m.sync.panel == "left" {
+			leftContent = m.renderSync()
+		} else {
+			rightContent = m.renderSync()
+		}
+	}
 
 	m.view = lipgloss.JoinHorizontal(lipgloss.Top, leftContent, rightContent)
+	if m.diff != nil {
+		m.view = m.renderDiff()
+	}
 
 	if m.errorMessage != "" {
 		return m.renderAlertDialog(m.errorMessage)
@@ -685,6 +1234,26 @@ func (m model) View() string {
 		return m.renderConfirmDialog(m.confirmMessage)
 	}
 
+	if m.inputMessage != "" {
+		return m.renderInputDialog(m.inputMessage)
+	}
+
+	if m.menuTitle != "" {
+		return m.renderMenuDialog()
+	}
+
+	if m.columnsPanel != "" {
+		return m.renderColumnsDialog()
+	}
+
+	if m.hotlistOpen {
+		return m.renderHotlistDialog()
+	}
+
+	if m.jumpOpen {
+		return m.renderJumpDialog()
+	}
+
 	if m.showHelp {
 		return m.renderHelpDialog()
 	}
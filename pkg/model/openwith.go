@@ -0,0 +1,110 @@
+package model
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/sandrolain/gommander/pkg/opener"
+)
+
+// openWith shows the applications that can open the highlighted file: the
+// matching opener rules, the desktop entries declaring its MIME type, the
+// default application and a free-form command.
+func (m *model) openWith() error {
+	path, err := m.getHighlightedRowPath(true)
+	if err != nil {
+		return fmt.Errorf("error getting highlighted row path: %v", err)
+	}
+	if path == "" {
+		return fmt.Errorf("no file selected")
+	}
+
+	mimeType := opener.DetectMIME(path)
+	choices := []opener.Choice{}
+
+	for _, rule := range m.config.Openers {
+		if rule.Matches(path, mimeType) {
+			choices = append(choices, opener.Choice{Label: rule.Command, Command: rule.Command, Terminal: rule.Terminal})
+		}
+	}
+
+	for _, entry := range opener.DesktopEntries(mimeType) {
+		choices = append(choices, opener.Choice{
+			Label:    fmt.Sprintf("%s (%s)", entry.Name, entry.ID),
+			Command:  entry.Exec,
+			Terminal: entry.Terminal,
+			Desktop:  true,
+		})
+	}
+
+	items := make([]string, 0, len(choices)+2)
+	for _, c := range choices {
+		items = append(items, c.Label)
+	}
+	items = append(items, "Default application", "Custom command...")
+
+	title := fmt.Sprintf("Open %s with", filepath.Base(path))
+	if mimeType != "" {
+		title += fmt.Sprintf(" (%s)", mimeType)
+	}
+
+	m.menuDialog(title, items, func(i int, m *model) error {
+		switch {
+		case i < len(choices):
+			m.confirmOpenWith(path, choices[i])
+		case i == len(choices):
+			return m.runOpenWith(opener.Default(path), false)
+		default:
+			m.inputDialog("Command (prefix with ! to run in the terminal):", func(value string, m *model) error {
+				value = strings.TrimSpace(value)
+				terminal := strings.HasPrefix(value, "!")
+				value = strings.TrimSpace(strings.TrimPrefix(value, "!"))
+				if value == "" {
+					return fmt.Errorf("Command cannot be empty")
+				}
+				m.confirmOpenWith(path, opener.Choice{Label: value, Command: value, Terminal: terminal})
+				return nil
+			})
+		}
+		return nil
+	})
+
+	return nil
+}
+
+// confirmOpenWith asks whether the choice has to be remembered as the
+// default for the type of the file, then opens it.
+func (m *model) confirmOpenWith(path string, choice opener.Choice) {
+	key := opener.TypeKey(path)
+
+	items := []string{"Open"}
+	if key != "" {
+		items = append(items, fmt.Sprintf("Open and always use for %s", key))
+	}
+
+	m.menuDialog(fmt.Sprintf("Open with %s", choice.Label), items, func(i int, m *model) error {
+		if i == 1 {
+			m.openDefaults[key] = choice
+			if err := opener.SaveDefaults(m.openDefaults); err != nil {
+				return fmt.Errorf("Error saving default opener: %v", err)
+			}
+		}
+
+		cmd, err := choice.Cmd(path)
+		if err != nil {
+			return fmt.Errorf("Error opening file: %v", err)
+		}
+		return m.runOpenWith(cmd, choice.Terminal)
+	})
+}
+
+func (m *model) runOpenWith(cmd *exec.Cmd, terminal bool) error {
+	teaCmd, err := runCommand(cmd, terminal)
+	if err != nil {
+		return err
+	}
+	m.pendingCmd = teaCmd
+	return nil
+}
@@ -2,10 +2,10 @@ package model
 
 import (
 	"fmt"
+	"io"
 	"os"
-	"path/filepath"
 
-	"github.com/sandrolain/gommander/pkg/fs"
+	"github.com/sandrolain/gommander/pkg/vfs"
 )
 
 func (m *model) createDirectory(name string) error {
@@ -15,8 +15,11 @@ func (m *model) createDirectory(name string) error {
 	} else {
 		currentPath = m.rightPanelDir
 	}
-	newDirPath := filepath.Join(currentPath, name)
-	err := os.Mkdir(newDirPath, os.ModePerm)
+	newDirPath := vfs.Join(currentPath, name)
+	fsys, fpath, err := vfs.Resolve(newDirPath)
+	if err == nil {
+		err = fsys.Mkdir(fpath, os.ModePerm)
+	}
 	if err != nil {
 		return fmt.Errorf("Error creating directory: %v", err)
 	}
@@ -35,8 +38,14 @@ func (m *model) createFile(name string) error {
 	} else {
 		currentPath = m.rightPanelDir
 	}
-	newFilePath := filepath.Join(currentPath, name)
-	err := os.WriteFile(newFilePath, []byte{}, os.ModePerm)
+	newFilePath := vfs.Join(currentPath, name)
+	fsys, fpath, err := vfs.Resolve(newFilePath)
+	if err == nil {
+		var f io.WriteCloser
+		if f, err = fsys.Create(fpath, os.ModePerm); err == nil {
+			err = f.Close()
+		}
+	}
 	if err != nil {
 		return fmt.Errorf("Error creating file: %v", err)
 	}
@@ -55,7 +64,7 @@ func (m *model) copyFiles(overwrite bool) error {
 	}
 
 	for _, filePath := range paths {
-		err := fs.CopyFile(filePath, destPath, overwrite)
+		err := vfs.Copy(filePath, destPath, overwrite)
 		if err != nil {
 			return err
 		}
@@ -76,7 +85,7 @@ func (m *model) moveFiles(overwrite bool) error {
 	}
 
 	for _, filePath := range paths {
-		err := fs.MoveFile(filePath, destPath, overwrite)
+		err := vfs.Move(filePath, destPath, overwrite)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,257 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sandrolain/gommander/pkg/archive"
+)
+
+var levelTitles = map[string]string{
+	archive.LevelDefault: "Default compression",
+	archive.LevelFastest: "Fastest",
+	archive.LevelBest:    "Best compression",
+	archive.LevelStore:   "No compression",
+}
+
+// archiveJob is a packing or an extraction running in the background, its
+// progress is shown in the footer of the target panel.
+type archiveJob struct {
+	panel    string
+	verb     string
+	name     string
+	progress archive.Progress
+	cancel   context.CancelFunc
+	msgs     chan tea.Msg
+}
+
+type archiveProgressMsg struct {
+	job      *archiveJob
+	progress archive.Progress
+}
+
+type archiveDoneMsg struct {
+	job *archiveJob
+	err error
+}
+
+func waitArchiveJob(job *archiveJob) tea.Cmd {
+	return func() tea.Msg {
+		return <-job.msgs
+	}
+}
+
+// startArchiveJob runs the packing or the extraction in the background,
+// writing to the panel that is not active.
+func (m *model) startArchiveJob(verb string, name string, run func(ctx context.Context, progress func(archive.Progress)) error) tea.Cmd {
+	panel := "right"
+	if m.active == "right" {
+		panel = "left"
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &archiveJob{
+		panel:  panel,
+		verb:   verb,
+		name:   name,
+		cancel: cancel,
+		msgs:   make(chan tea.Msg, 1),
+	}
+	m.archiveJob = job
+
+	go func() {
+		err := run(ctx, func(p archive.Progress) {
+			// Drop the report if the previous one was not handled yet
+			select {
+			case job.msgs <- archiveProgressMsg{job: job, progress: p}:
+			default:
+			}
+		})
+		job.msgs <- archiveDoneMsg{job: job, err: err}
+	}()
+
+	return waitArchiveJob(job)
+}
+
+func (m *model) cancelArchiveJob() {
+	if m.archiveJob != nil {
+		m.archiveJob.cancel()
+	}
+}
+
+func (m *model) updateArchiveJob(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case archiveProgressMsg:
+		if msg.job != m.archiveJob {
+			return nil
+		}
+		m.archiveJob.progress = msg.progress
+		return waitArchiveJob(m.archiveJob)
+	case archiveDoneMsg:
+		if msg.job != m.archiveJob {
+			return nil
+		}
+		m.archiveJob = nil
+		m.refreshTablesRows(true, true)
+		if msg.err != nil && !errors.Is(msg.err, context.Canceled) {
+			m.showError(fmt.Sprintf("Error %s %s: %v", strings.ToLower(msg.job.verb), msg.job.name, msg.err))
+		}
+	}
+	return nil
+}
+
+func (m *model) archiveJobStatus(panel string) string {
+	job := m.archiveJob
+	if job == nil || job.panel != panel {
+		return ""
+	}
+	percent := 0
+	if job.progress.Total > 0 {
+		percent = int(job.progress.Bytes * 100 / job.progress.Total)
+	}
+	return fmt.Sprintf(" | %s %s: %d%% (%d files)", job.verb, job.name, min(percent, 100), job.progress.Files)
+}
+
+// archivePaths returns the paths of the selection and the directory of the
+// other panel, both on the local file system.
+func (m *model) archivePaths() ([]string, string, error) {
+	if m.archiveJob != nil {
+		return nil, "", fmt.Errorf("Wait for the %s of %s to finish", strings.ToLower(m.archiveJob.verb), m.archiveJob.name)
+	}
+
+	srcPath, _ := m.getSourceDirPath()
+	destPath, _ := m.getDestinationDirPath()
+	if !localDir(srcPath) || !localDir(destPath) {
+		return nil, "", fmt.Errorf("Only available on the local file system")
+	}
+
+	paths, err := m.getCurrentRowsPaths()
+	if err != nil || len(paths) == 0 || paths[0] == "" {
+		return nil, "", fmt.Errorf("Select the files to archive or extract")
+	}
+	return paths, destPath, nil
+}
+
+// packDialog asks for the format, the compression level and the name of the
+// archive of the selection, created in the other panel.
+func (m *model) packDialog() {
+	paths, destPath, err := m.archivePaths()
+	if err != nil {
+		m.showError(err.Error())
+		return
+	}
+
+	m.menuDialog("Archive format", archive.Formats, func(i int, m *model) error {
+		format := archive.Formats[i]
+		levels := archive.Levels(format)
+		if len(levels) == 0 {
+			m.packNameDialog(paths, destPath, format, archive.LevelDefault)
+			return nil
+		}
+
+		items := []string{}
+		for _, level := range levels {
+			items = append(items, levelTitles[level])
+		}
+		m.menuDialog("Compression level", items, func(i int, m *model) error {
+			m.packNameDialog(paths, destPath, format, levels[i])
+			return nil
+		})
+		return nil
+	})
+}
+
+func (m *model) packNameDialog(paths []string, destPath string, format string, level string) {
+	name := filepath.Base(paths[0])
+	if len(paths) > 1 {
+		name = filepath.Base(filepath.Dir(paths[0]))
+	}
+
+	m.inputDialog("Enter the name of the archive:", func(value string, m *model) error {
+		if value == "" {
+			return fmt.Errorf("Archive name cannot be empty")
+		}
+		if !strings.HasSuffix(strings.ToLower(value), "."+format) {
+			value += "." + format
+		}
+		dst := filepath.Join(destPath, value)
+
+		pack := func(m *model) error {
+			m.pendingCmd = m.startArchiveJob("Packing", value, func(ctx context.Context, progress func(archive.Progress)) error {
+				return archive.Create(ctx, dst, paths, format, level, progress)
+			})
+			return nil
+		}
+		if _, err := os.Lstat(dst); err == nil {
+			m.confirmDialog(fmt.Sprintf("%s already exists, overwrite it?", dst), pack)
+			return nil
+		}
+		return pack(m)
+	})
+	m.inputValue = name + "." + format
+}
+
+// extractDialog extracts the selected archives to the directory of the
+// other panel, asking what to do with the files already there.
+func (m *model) extractDialog() {
+	paths, destPath, err := m.archivePaths()
+	if err != nil {
+		m.showError(err.Error())
+		return
+	}
+
+	archives := []*archive.Archive{}
+	conflicts := 0
+	for _, path := range paths {
+		if !archive.Supported(path) {
+			m.showError(fmt.Sprintf("%s is not a supported archive", filepath.Base(path)))
+			return
+		}
+		a, err := archive.Open(path)
+		if err != nil {
+			m.showError(fmt.Sprintf("Error opening archive: %v", err))
+			return
+		}
+		archives = append(archives, a)
+		conflicts += len(a.Conflicts(destPath))
+	}
+
+	extract := func(m *model, conflict string) {
+		name := filepath.Base(paths[0])
+		if len(paths) > 1 {
+			name = fmt.Sprintf("%d archives", len(paths))
+		}
+		m.pendingCmd = m.startArchiveJob("Extracting", name, func(ctx context.Context, progress func(archive.Progress)) error {
+			for _, a := range archives {
+				if err := a.ExtractAll(ctx, destPath, conflict, progress); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+
+	if conflicts > 0 {
+		items := []string{"Overwrite existing files", "Skip existing files", "Cancel"}
+		m.menuDialog(fmt.Sprintf("%d files already exist in %s", conflicts, destPath), items, func(i int, m *model) error {
+			switch i {
+			case 0:
+				extract(m, archive.ConflictOverwrite)
+			case 1:
+				extract(m, archive.ConflictSkip)
+			}
+			return nil
+		})
+		return
+	}
+
+	m.confirmAction(m.config.Confirm.Copy, fmt.Sprintf("Extract\n%s\nto %s?", strings.Join(paths, "\n"), destPath), func(m *model) error {
+		extract(m, archive.ConflictOverwrite)
+		return nil
+	})
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"strings"
+
+	"github.com/sandrolain/gommander/pkg/preview"
+)
+
+func (m *model) updatePreview() {
+	if !m.showPreview {
+		return
+	}
+
+	path, err := m.getHighlightedRowPath(false)
+	if err != nil {
+		return
+	}
+
+	if m.preview.Path != path || m.preview.Width != m.previewWidth || m.preview.Height != m.previewHeight {
+		m.preview = preview.Render(path, m.previewWidth, m.previewHeight)
+	}
+
+	if m.preview.Overlay != "" {
+		// Force the redraw of the overlay line, the lines written in the
+		// meantime may have erased part of the image.
+		m.previewFrame++
+	}
+}
+
+func (m *model) renderPreview() string {
+	content := m.preview.Content
+	if m.preview.Overlay != "" {
+		content += m.preview.Overlay + strings.Repeat("\x1b[0m", m.previewFrame%2)
+	}
+	if m.previewFocused {
+		return previewFocusedStyle.Render(content)
+	}
+	return previewStyle.Render(content)
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/sandrolain/gommander/pkg/rows"
+)
+
+var sortTitles = map[string]string{
+	"name":     "Name",
+	"size":     "Size",
+	"modified": "Modified",
+	"ext":      "Extension",
+}
+
+func (m *model) sortDialog() {
+	options := []rows.SortOptions{}
+	items := []string{}
+	current := m.panelSort(m.active)
+
+	for _, key := range rows.SortKeys {
+		for _, reverse := range []bool{false, true} {
+			opts := *current
+			opts.By = key
+			opts.Reverse = reverse
+
+			item := sortTitles[key]
+			if reverse {
+				item += " (reverse)"
+			}
+			options = append(options, opts)
+			items = append(items, markCurrent(item, opts == *current))
+		}
+	}
+
+	dirsFirst := "Directories first: on"
+	if current.DirsFirst {
+		dirsFirst = "Directories first: off"
+	}
+	items = append(items, fmt.Sprintf("Toggle %s", dirsFirst))
+
+	// The sort is the one of the active tab
+	m.menuDialog("Sort by", items, func(i int, m *model) error {
+		current := m.panelSort(m.active)
+		if i < len(options) {
+			*current = options[i]
+		} else {
+			current.DirsFirst = !current.DirsFirst
+		}
+		m.refreshTablesRows(true, false)
+		return nil
+	})
+}
@@ -0,0 +1,439 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/sandrolain/gommander/pkg/dirsync"
+	"github.com/sandrolain/gommander/pkg/preview"
+)
+
+var syncModeTitles = map[string]string{
+	dirsync.ModeMirror: "Mirror",
+	dirsync.ModeUpdate: "Update",
+	dirsync.ModeTwoWay: "Two-way",
+}
+
+// syncWizard is the state of the synchronization, the plan is shown in
+// place of the table of the target panel.
+type syncWizard struct {
+	panel    string
+	mode     string
+	src      string
+	dst      string
+	items    []dirsync.Item
+	cursor   int
+	top      int
+	planning bool
+	running  bool
+	progress dirsync.Progress
+	dryRun   bool
+	status   string
+	seq      int
+	cancel   context.CancelFunc
+	msgs     chan tea.Msg
+}
+
+type syncPlanMsg struct {
+	wizard *syncWizard
+	seq    int
+	items  []dirsync.Item
+	err    error
+}
+
+type syncProgressMsg struct {
+	wizard   *syncWizard
+	progress dirsync.Progress
+}
+
+type syncDoneMsg struct {
+	wizard *syncWizard
+	done   int
+	err    error
+}
+
+func (m *model) syncDialog() {
+	src, dst := m.leftPanelDir, m.rightPanelDir
+	arrow := "→"
+	if m.active == "right" {
+		src, dst = dst, src
+		arrow = "←"
+	}
+	if src == dst {
+		m.showError("Both panels show the same directory")
+		return
+	}
+	if m.refuseRemote(src, dst) {
+		return
+	}
+
+	items := []string{}
+	for _, mode := range dirsync.Modes {
+		if mode == dirsync.ModeTwoWay {
+			items = append(items, syncModeTitles[mode]+" (⇄)")
+		} else {
+			items = append(items, fmt.Sprintf("%s (%s)", syncModeTitles[mode], arrow))
+		}
+	}
+
+	m.menuDialog("Synchronize directories", items, func(i int, m *model) error {
+		panel := "right"
+		if m.active == "right" {
+			panel = "left"
+		}
+		m.sync = &syncWizard{
+			panel: panel,
+			mode:  dirsync.Modes[i],
+			src:   src,
+			dst:   dst,
+		}
+		m.pendingCmd = m.planSync()
+		return nil
+	})
+}
+
+// planSync computes the plan in the background.
+func (m *model) planSync() tea.Cmd {
+	w := m.sync
+	if w.cancel != nil {
+		w.cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	w.planning = true
+	w.cancel = cancel
+	w.seq++
+
+	seq := w.seq
+	return func() tea.Msg {
+		items, err := dirsync.Plan(ctx, w.src, w.dst, w.mode)
+		return syncPlanMsg{wizard: w, seq: seq, items: items, err: err}
+	}
+}
+
+func (m *model) stopSync() {
+	if m.sync != nil {
+		m.sync.cancel()
+		m.sync = nil
+	}
+}
+
+func (m *model) updateSyncPlan(msg syncPlanMsg) {
+	if msg.wizard != m.sync || msg.seq != m.sync.seq {
+		return
+	}
+	if msg.err != nil {
+		m.sync = nil
+		if !errors.Is(msg.err, context.Canceled) {
+			m.showError(fmt.Sprintf("Error planning the synchronization: %v", msg.err))
+		}
+		return
+	}
+	m.sync.planning = false
+	m.sync.items = msg.items
+	m.sync.scroll(m.syncPageSize())
+}
+
+func (m *model) updateSync(key string) tea.Cmd {
+	w := m.sync
+
+	switch key {
+	case KeyQuit:
+		return tea.Quit
+	case KeyHelp:
+		m.showHelp = !m.showHelp
+		return nil
+	case KeyCancel:
+		if w.running {
+			// Stop after the item in progress
+			w.cancel()
+			return nil
+		}
+		m.stopSync()
+		return nil
+	}
+
+	if w.planning || w.running {
+		return nil
+	}
+
+	switch key {
+	case "up", "k":
+		w.cursor--
+	case "down", "j":
+		w.cursor++
+	case "pgup":
+		w.cursor -= m.syncPageSize()
+	case "pgdown":
+		w.cursor += m.syncPageSize()
+	case "home", "g":
+		w.cursor = 0
+	case "end", "G":
+		w.cursor = len(w.items) - 1
+	case KeySelect:
+		if w.cursor < len(w.items) {
+			w.items[w.cursor].Enabled = !w.items[w.cursor].Enabled
+			w.cursor++
+		}
+	case "a":
+		enable := false
+		for _, it := range w.items {
+			if !it.Enabled {
+				enable = true
+			}
+		}
+		for i := range w.items {
+			w.items[i].Enabled = enable
+		}
+	case "d":
+		w.dryRun = !w.dryRun
+		w.status = ""
+	case "r":
+		w.status = ""
+		return m.planSync()
+	case KeyEnter:
+		return m.runSync()
+	}
+
+	w.scroll(m.syncPageSize())
+	return nil
+}
+
+// scroll keeps the cursor on an item and in the page shown.
+func (w *syncWizard) scroll(pageSize int) {
+	w.cursor = max(0, min(w.cursor, len(w.items)-1))
+	if w.cursor < w.top {
+		w.top = w.cursor
+	}
+	if w.cursor >= w.top+pageSize {
+		w.top = w.cursor - pageSize + 1
+	}
+}
+
+type syncSummary struct {
+	copies     int
+	overwrites int
+	deletes    int
+	bytes      uint64
+}
+
+func (w *syncWizard) summary() syncSummary {
+	s := syncSummary{}
+	for _, it := range w.items {
+		if !it.Enabled {
+			continue
+		}
+		switch it.Action {
+		case dirsync.ActionCopy:
+			s.copies++
+			s.bytes += it.Size
+		case dirsync.ActionOverwrite:
+			s.overwrites++
+			s.bytes += it.Size
+		case dirsync.ActionDelete:
+			s.deletes++
+		}
+	}
+	return s
+}
+
+func (m *model) syncSummaryText(s syncSummary) string {
+	return fmt.Sprintf("%d copies, %d overwrites, %d deletes, %s to transfer", s.copies, s.overwrites, s.deletes, m.rowsOptions.Format.FormatSize(s.bytes))
+}
+
+// runSync executes the enabled items of the plan, or only reports them in
+// dry run mode, then plans again to show what is left.
+func (m *model) runSync() tea.Cmd {
+	w := m.sync
+	s := w.summary()
+	if s.copies+s.overwrites+s.deletes == 0 {
+		return nil
+	}
+
+	if w.dryRun {
+		w.status = "Dry run: " + m.syncSummaryText(s)
+		return nil
+	}
+
+	required := m.config.Confirm.Copy
+	if s.deletes > 0 || s.overwrites > 0 {
+		required = required || m.config.Confirm.Delete
+	}
+	text := fmt.Sprintf("Synchronize %s\nto %s?\n%s", w.src, w.dst, m.syncSummaryText(s))
+	if w.mode == dirsync.ModeTwoWay {
+		text = fmt.Sprintf("Synchronize %s\nand %s?\n%s", w.src, w.dst, m.syncSummaryText(s))
+	}
+
+	m.confirmAction(required, text, func(m *model) error {
+		m.pendingCmd = m.startSyncRun()
+		return nil
+	})
+	return m.takePendingCmd()
+}
+
+func waitSyncRun(w *syncWizard) tea.Cmd {
+	return func() tea.Msg {
+		return <-w.msgs
+	}
+}
+
+// startSyncRun executes the plan in the background, reporting the progress
+// in place of the plan.
+func (m *model) startSyncRun() tea.Cmd {
+	w := m.sync
+	ctx, cancel := context.WithCancel(context.Background())
+	w.running = true
+	w.progress = dirsync.Progress{}
+	w.status = ""
+	w.cancel = cancel
+	w.msgs = make(chan tea.Msg, 1)
+
+	items := append([]dirsync.Item{}, w.items...)
+	go func() {
+		done, err := dirsync.Run(ctx, items, func(p dirsync.Progress) {
+			// Drop the report if the previous one was not handled yet
+			select {
+			case w.msgs <- syncProgressMsg{wizard: w, progress: p}:
+			default:
+			}
+		})
+		w.msgs <- syncDoneMsg{wizard: w, done: done, err: err}
+	}()
+
+	return waitSyncRun(w)
+}
+
+func (m *model) updateSyncRun(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case syncProgressMsg:
+		msg.wizard.progress = msg.progress
+		return waitSyncRun(msg.wizard)
+	case syncDoneMsg:
+		w := msg.wizard
+		w.running = false
+		m.refreshTablesRows(true, true)
+
+		canceled := errors.Is(msg.err, context.Canceled)
+		w.status = fmt.Sprintf("Synchronized %d items", msg.done)
+		if canceled {
+			w.status = fmt.Sprintf("Stopped after %d items", msg.done)
+		} else if msg.err != nil {
+			m.showError(fmt.Sprintf("Error synchronizing %v", msg.err))
+		}
+		if m.sync == w {
+			return m.planSync()
+		}
+	}
+	return nil
+}
+
+func (m *model) syncPageSize() int {
+	// Title, summary, status and key hints
+	return max(1, m.previewHeight-4)
+}
+
+func (m *model) renderSync() string {
+	w := m.sync
+	width := m.previewWidth
+	height := m.previewHeight
+
+	arrow := "→"
+	if w.mode == dirsync.ModeTwoWay {
+		arrow = "⇄"
+	}
+	title := fmt.Sprintf("%s: %s %s %s", syncModeTitles[w.mode], w.src, arrow, w.dst)
+
+	if w.planning {
+		lines := []string{
+			tableHeaderStyle.Render(ansi.Truncate(title, width, "…")),
+			"",
+			"Comparing directories...",
+			"",
+			lipgloss.NewStyle().Faint(true).Render("esc: stop"),
+		}
+		return previewFocusedStyle.Render(preview.FitLines(lines, width, height))
+	}
+
+	if w.running {
+		p := w.progress
+		percent := 0
+		if p.TotalBytes > 0 {
+			percent = int(p.Bytes * 100 / p.TotalBytes)
+		} else if p.Total > 0 {
+			percent = p.Items * 100 / p.Total
+		}
+		lines := []string{
+			tableHeaderStyle.Render(ansi.Truncate(title, width, "…")),
+			"",
+			fmt.Sprintf("Synchronizing: %d%% (%d of %d items)", min(percent, 100), p.Items, p.Total),
+			ansi.Truncate(p.Rel, width, "…"),
+			"",
+			lipgloss.NewStyle().Faint(true).Render("esc: stop after the current item"),
+		}
+		return previewFocusedStyle.Render(preview.FitLines(lines, width, height))
+	}
+
+	dryRun := "off"
+	if w.dryRun {
+		dryRun = "on"
+	}
+	lines := []string{
+		tableHeaderStyle.Render(ansi.Truncate(title, width, "…")),
+		fmt.Sprintf("Planned: %s | Dry run: %s", m.syncSummaryText(w.summary()), dryRun),
+		w.status,
+	}
+
+	pageSize := m.syncPageSize()
+
+	for i := w.top; i < len(w.items) && i < w.top+pageSize; i++ {
+		line := m.syncLine(w.items[i])
+		if i == w.cursor {
+			line = highlightStyle().Width(width).Render(ansi.Truncate(line, width, "…"))
+		} else if !w.items[i].Enabled {
+			line = lipgloss.NewStyle().Faint(true).Render(line)
+		}
+		lines = append(lines, line)
+	}
+
+	if len(w.items) == 0 {
+		lines = append(lines, lipgloss.NewStyle().Faint(true).Render("The directories are in sync"))
+	}
+
+	hints := fmt.Sprintf("%s: toggle  a: all  d: dry run  r: replan  %s: run  %s: close", KeySelect, KeyEnter, KeyCancel)
+	content := preview.FitLines(lines, width, height-1) + "\n" + preview.FitLines([]string{lipgloss.NewStyle().Faint(true).Render(hints)}, width, 1)
+	return previewFocusedStyle.Render(content)
+}
+
+func (m *model) syncLine(it dirsync.Item) string {
+	check := "[ ]"
+	if it.Enabled {
+		check = "[x]"
+	}
+
+	action := string(it.Action)
+	if it.Conflict {
+		action = "conflict"
+	}
+
+	arrow := "→"
+	if it.Reverse {
+		arrow = "←"
+	}
+	if it.Action == dirsync.ActionDelete {
+		arrow = "✗"
+	}
+
+	name := it.Rel
+	if it.Dir {
+		name += "/"
+	}
+
+	size := ""
+	if it.Action != dirsync.ActionDelete {
+		size = m.rowsOptions.Format.FormatSize(it.Size)
+	}
+	return fmt.Sprintf("%s %-9s %s %10s %s", check, action, arrow, size, name)
+}
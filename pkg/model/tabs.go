@@ -0,0 +1,279 @@
+package model
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/evertras/bubble-table/table"
+	"github.com/sandrolain/gommander/pkg/rows"
+	"github.com/sandrolain/gommander/pkg/state"
+	"github.com/sandrolain/gommander/pkg/vfs"
+)
+
+const tabsStateFile = "tabs.json"
+
+// tab is a directory open in a panel. The active tab of each panel lives in
+// the panel fields of the model, the others are kept in the tabs.
+type tab struct {
+	dir       string
+	table     table.Model
+	filesInfo rows.FilesInfo
+	sort      rows.SortOptions
+	filter    string
+	history   *history
+}
+
+type savedTab struct {
+	Dir    string           `json:"dir"`
+	Cursor string           `json:"cursor,omitempty"`
+	Sort   rows.SortOptions `json:"sort"`
+	Filter string           `json:"filter,omitempty"`
+}
+
+type savedPanelTabs struct {
+	Tabs   []savedTab `json:"tabs"`
+	Active int        `json:"active"`
+}
+
+// savedTabs is persisted on exit to restore the tabs on restart.
+type savedTabs struct {
+	Left  savedPanelTabs `json:"left"`
+	Right savedPanelTabs `json:"right"`
+}
+
+func (m *model) panelTabs(panel string) (*[]tab, *int) {
+	if panel == "left" {
+		return &m.leftTabs, &m.leftTab
+	}
+	return &m.rightTabs, &m.rightTab
+}
+
+func (m *model) panelDir(panel string) string {
+	if panel == "left" {
+		return m.leftPanelDir
+	}
+	return m.rightPanelDir
+}
+
+func (m *model) panelSort(panel string) *rows.SortOptions {
+	if panel == "left" {
+		return &m.leftSort
+	}
+	return &m.rightSort
+}
+
+func (m *model) panelFilter(panel string) *string {
+	if panel == "left" {
+		return &m.leftFilter
+	}
+	return &m.rightFilter
+}
+
+// panelOptions returns the options listing the directory of the active tab
+// of the panel, with its sort and filter.
+func (m *model) panelOptions(panel string) rows.Options {
+	opts := m.rowsOptions
+	opts.Sort = *m.panelSort(panel)
+	opts.Filter = *m.panelFilter(panel)
+	return opts
+}
+
+// saveTab stores the panel fields in the active tab of the panel.
+func (m *model) saveTab(panel string) {
+	tabs, i := m.panelTabs(panel)
+	t := &(*tabs)[*i]
+	if panel == "left" {
+		t.dir, t.table, t.filesInfo, t.sort, t.filter = m.leftPanelDir, m.leftTable, m.leftFilesInfo, m.leftSort, m.leftFilter
+		t.history = m.leftHistory
+	} else {
+		t.dir, t.table, t.filesInfo, t.sort, t.filter = m.rightPanelDir, m.rightTable, m.rightFilesInfo, m.rightSort, m.rightFilter
+		t.history = m.rightHistory
+	}
+}
+
+// loadTab makes the tab i the active one of the panel, moving it to the
+// panel fields, listing its directory again and watching it.
+func (m *model) loadTab(panel string, i int) error {
+	tabs, active := m.panelTabs(panel)
+	*active = i
+	t := (*tabs)[i]
+	t.table = m.sizeTable(t.table).Focused(m.active == panel).HeaderStyle(tableHeaderStyle)
+
+	watch := watchDir(t.dir)
+	if panel == "left" {
+		m.leftPanelDir, m.leftTable, m.leftFilesInfo, m.leftSort, m.leftFilter = t.dir, t.table, t.filesInfo, t.sort, t.filter
+		m.leftHistory = t.history
+		m.refreshLeftTableRows()
+		if watch != "" {
+			if err := m.updateLeftWatcher(watch, func() {
+				m.refreshLeftTableRows()
+			}); err != nil {
+				return fmt.Errorf("error creating watcher: %v", err)
+			}
+		}
+	} else {
+		m.rightPanelDir, m.rightTable, m.rightFilesInfo, m.rightSort, m.rightFilter = t.dir, t.table, t.filesInfo, t.sort, t.filter
+		m.rightHistory = t.history
+		m.refreshRightTableRows()
+		if watch != "" {
+			if err := m.updateRightWatcher(watch, func() {
+				m.refreshRightTableRows()
+			}); err != nil {
+				return fmt.Errorf("error creating watcher: %v", err)
+			}
+		}
+	}
+	m.autoDirSizes(panel)
+	return nil
+}
+
+// newTab opens the directory of the active tab in a new tab next to it, with
+// a copy of its history.
+func (m *model) newTab() error {
+	m.saveTab(m.active)
+	tabs, i := m.panelTabs(m.active)
+
+	t := (*tabs)[*i]
+	t.history = t.history.clone()
+	// Not sharing the rows, listed again when loaded
+	t.table = t.table.WithRows([]table.Row{})
+
+	*tabs = append((*tabs)[:*i+1], append([]tab{t}, (*tabs)[*i+1:]...)...)
+	return m.loadTab(m.active, *i+1)
+}
+
+func (m *model) closeTab() error {
+	tabs, i := m.panelTabs(m.active)
+	if len(*tabs) == 1 {
+		return fmt.Errorf("Cannot close the last tab")
+	}
+	*tabs = append((*tabs)[:*i], (*tabs)[*i+1:]...)
+	return m.loadTab(m.active, min(*i, len(*tabs)-1))
+}
+
+func (m *model) cycleTab(delta int) error {
+	tabs, i := m.panelTabs(m.active)
+	if len(*tabs) == 1 {
+		return nil
+	}
+	m.saveTab(m.active)
+	return m.loadTab(m.active, (*i+delta+len(*tabs))%len(*tabs))
+}
+
+// tabBar renders the tabs of the panel, numbered, with their directory and
+// filter.
+func (m *model) tabBar(panel string) string {
+	tabs, active := m.panelTabs(panel)
+	labels := []string{}
+	for i, t := range *tabs {
+		dir, filter := t.dir, t.filter
+		if i == *active {
+			dir, filter = m.panelDir(panel), *m.panelFilter(panel)
+		}
+
+		label := fmt.Sprintf("%d %s", i+1, vfs.Base(dir))
+		if filter != "" {
+			label += fmt.Sprintf(" [%s]", filter)
+		}
+		style := tabStyle
+		if i == *active {
+			style = tabActiveStyle.Faint(m.active != panel)
+		}
+		labels = append(labels, style.Render(label))
+	}
+	return ansi.Truncate(strings.Join(labels, ""), m.panelWidth, "…")
+}
+
+// restoreTabs opens the tabs saved on the last exit, skipping the
+// directories that are gone and the remote ones.
+func (m *model) restoreTabs() error {
+	var saved savedTabs
+	if err := state.Load(tabsStateFile, &saved); err != nil {
+		return err
+	}
+
+	for _, panel := range []string{"left", "right"} {
+		p := saved.Left
+		columns := m.layout.Left
+		if panel == "right" {
+			p = saved.Right
+			columns = m.layout.Right
+		}
+
+		tabs := []tab{}
+		active := 0
+		for i, st := range p.Tabs {
+			if !vfs.IsLocal(st.Dir) {
+				continue
+			}
+			if info, err := os.Stat(st.Dir); err != nil || !info.IsDir() {
+				continue
+			}
+			if !rows.ValidSortKey(st.Sort.By) || !rows.ValidFilter(st.Filter) {
+				st.Sort, st.Filter = m.rowsOptions.Sort, ""
+			}
+
+			opts := m.rowsOptions
+			opts.Sort, opts.Filter = st.Sort, st.Filter
+			filesInfo, t := createTable(st.Dir, columns, opts)
+			t = t.WithHighlightedRow(rowIndex(t, st.Cursor))
+			if i <= p.Active {
+				active = len(tabs)
+			}
+			tabs = append(tabs, tab{dir: st.Dir, table: t, filesInfo: filesInfo, sort: st.Sort, filter: st.Filter})
+		}
+		if len(tabs) == 0 {
+			continue
+		}
+
+		all, _ := m.panelTabs(panel)
+		*all = tabs
+		if err := m.loadTab(panel, active); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rowIndex returns the index of the row with the name, 0 if missing.
+func rowIndex(t table.Model, name string) int {
+	for i, row := range t.GetVisibleRows() {
+		if row.Data["name"] == name {
+			return i
+		}
+	}
+	return 0
+}
+
+func (m *model) saveTabs() error {
+	m.saveTab("left")
+	m.saveTab("right")
+
+	var saved savedTabs
+	for _, panel := range []string{"left", "right"} {
+		tabs, active := m.panelTabs(panel)
+		p := savedPanelTabs{Active: *active}
+		for _, t := range *tabs {
+			cursor, _ := t.table.HighlightedRow().Data["name"].(string)
+			p.Tabs = append(p.Tabs, savedTab{Dir: t.dir, Cursor: cursor, Sort: t.sort, Filter: t.filter})
+		}
+		if panel == "left" {
+			saved.Left = p
+		} else {
+			saved.Right = p
+		}
+	}
+	return state.Save(tabsStateFile, saved)
+}
+
+// SaveTabs persists the tabs of the model returned by the program on exit.
+func SaveTabs(tm tea.Model) error {
+	m, ok := tm.(model)
+	if !ok {
+		return nil
+	}
+	return m.saveTabs()
+}
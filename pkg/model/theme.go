@@ -0,0 +1,173 @@
+package model
+
+import (
+	"io/fs"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/evertras/bubble-table/table"
+	"github.com/sandrolain/gommander/pkg/preview"
+	"github.com/sandrolain/gommander/pkg/theme"
+)
+
+var (
+	currentTheme  theme.Theme
+	currentColors theme.Colors
+	lsColors      *theme.LSColors
+)
+
+var (
+	footLSty            lipgloss.Style
+	footVSty            lipgloss.Style
+	dialogBoxStyle      lipgloss.Style
+	buttonStyle         lipgloss.Style
+	activeButtonStyle   lipgloss.Style
+	inputStyle          lipgloss.Style
+	tableNormalStyle    lipgloss.Style
+	tableActiveStyle    lipgloss.Style
+	tableHeaderStyle    lipgloss.Style
+	previewStyle        lipgloss.Style
+	previewFocusedStyle lipgloss.Style
+	tabStyle            lipgloss.Style
+	tabActiveStyle      lipgloss.Style
+	diffDeleteStyle     lipgloss.Style
+	diffInsertStyle     lipgloss.Style
+)
+
+func init() {
+	applyTheme(theme.Default())
+}
+
+// themeColor maps the empty string to the terminal default colour.
+func themeColor(c string) lipgloss.TerminalColor {
+	if c == "" {
+		return lipgloss.NoColor{}
+	}
+	return lipgloss.Color(c)
+}
+
+func applyTheme(t theme.Theme) error {
+	currentTheme = t
+	currentColors = t.Resolve()
+	c := currentColors
+
+	lsColors = nil
+	var err error
+	if t.LSColors && !t.Mono() {
+		lsColors, err = theme.LoadLSColors(t.Dircolors)
+	}
+
+	footLSty = lipgloss.NewStyle().
+		Foreground(themeColor(c.FooterLabel))
+
+	footVSty = lipgloss.NewStyle().
+		Foreground(themeColor(c.FooterValue))
+
+	dialogBoxStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(themeColor(c.DialogBorder)).
+		Padding(1, 2).
+		Margin(1, 1).
+		BorderTop(true).
+		BorderLeft(true).
+		BorderRight(true).
+		BorderBottom(true)
+
+	buttonStyle = lipgloss.NewStyle().
+		Foreground(themeColor(c.ButtonFg)).
+		Background(themeColor(c.ButtonBg)).
+		Padding(0, 3).
+		MarginLeft(1)
+
+	activeButtonStyle = buttonStyle.
+		Foreground(themeColor(c.ButtonFg)).
+		Background(themeColor(c.ButtonActiveBg)).
+		Reverse(c.ButtonActiveBg == "").
+		Padding(0, 3)
+
+	inputStyle = lipgloss.NewStyle().
+		Foreground(themeColor(c.InputFg)).
+		Background(themeColor(c.InputBg)).
+		Underline(c.InputBg == "")
+
+	tableNormalStyle = lipgloss.NewStyle().
+		BorderForeground(themeColor(c.BorderInactive)).Faint(true).
+		Align(lipgloss.Right)
+
+	tableActiveStyle = lipgloss.NewStyle().
+		BorderForeground(themeColor(c.BorderActive)).
+		Align(lipgloss.Right)
+
+	tableHeaderStyle = lipgloss.NewStyle().Bold(true).Foreground(themeColor(c.Header))
+
+	previewStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(themeColor(c.BorderInactive))
+
+	previewFocusedStyle = previewStyle.
+		BorderForeground(themeColor(c.BorderActive))
+
+	tabStyle = lipgloss.NewStyle().
+		Foreground(themeColor(c.FooterLabel)).
+		Padding(0, 1)
+
+	tabActiveStyle = tabStyle.
+		Foreground(themeColor(c.Header)).
+		Bold(true).
+		Underline(true)
+
+	diffDeleteStyle = lipgloss.NewStyle().Foreground(themeColor(c.Deleted))
+	diffInsertStyle = lipgloss.NewStyle().Foreground(themeColor(c.Inserted))
+
+	preview.SetTheme(t)
+
+	return err
+}
+
+func (m *model) themeDialog() {
+	items := make([]string, len(theme.Presets))
+	for i, name := range theme.Presets {
+		items[i] = markCurrent(name, name == currentTheme.Preset)
+	}
+
+	m.menuDialog("Theme", items, func(i int, m *model) error {
+		t := currentTheme
+		t.Preset = theme.Presets[i]
+		err := applyTheme(t)
+		m.leftTable = m.leftTable.HeaderStyle(tableHeaderStyle)
+		m.rightTable = m.rightTable.HeaderStyle(tableHeaderStyle)
+		// Render the preview again with the colours of the theme
+		m.preview = preview.Preview{}
+		m.updatePreview()
+		return err
+	})
+}
+
+func highlightStyle() lipgloss.Style {
+	c := currentColors
+	return lipgloss.NewStyle().Bold(true).Background(themeColor(c.HighlightBg)).Foreground(themeColor(c.HighlightFg)).Reverse(c.HighlightBg == "")
+}
+
+func rowStyle(rsfi table.RowStyleFuncInput) lipgloss.Style {
+	c := currentColors
+
+	if rsfi.IsHighlighted {
+		return highlightStyle()
+	}
+
+	if rsfi.Row.Data["name"] == ".." {
+		return lipgloss.NewStyle().Foreground(themeColor(c.Parent)).Faint(c.Parent == "")
+	}
+
+	if mode, ok := rsfi.Row.Data["umode"].(fs.FileMode); ok {
+		name, _ := rsfi.Row.Data["name"].(string)
+		if style, ok := lsColors.Style(name, mode); ok {
+			return style
+		}
+	}
+
+	if rsfi.Row.Data["dir"] == true {
+		return lipgloss.NewStyle().Foreground(themeColor(c.Directory)).Bold(c.Directory == "")
+	}
+
+	return lipgloss.NewStyle().Foreground(themeColor(c.File))
+}
@@ -0,0 +1,99 @@
+package model
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sandrolain/gommander/pkg/vfs"
+)
+
+// refuseReadOnly shows an error and returns true if any of the directories
+// is on a read-only file system, like the archives.
+func (m *model) refuseReadOnly(dirs ...string) bool {
+	for _, dir := range dirs {
+		if vfs.ReadOnly(dir) || vfs.IsArchive(dir) {
+			m.showError("The file system is read-only")
+			return true
+		}
+	}
+	return false
+}
+
+// refuseRemote shows an error and returns true if any of the directories is
+// not on the local file system.
+func (m *model) refuseRemote(dirs ...string) bool {
+	for _, dir := range dirs {
+		if !localDir(dir) {
+			m.showError("Only available on the local file system")
+			return true
+		}
+	}
+	return false
+}
+
+// localDir reports whether the directory of a panel is on the local file
+// system, the archives shown as directories not being.
+func localDir(dir string) bool {
+	return vfs.IsLocal(dir) && !vfs.IsArchive(dir)
+}
+
+// statPath returns the information of a file of any backend.
+func statPath(path string) (fs.FileInfo, error) {
+	fsys, fpath, err := vfs.Resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	return fsys.Stat(fpath)
+}
+
+// watchDir returns the local directory to watch for the changes of the
+// panel, the directory containing the archive for the archives and none for
+// the other backends.
+func watchDir(path string) string {
+	fsys, _, err := vfs.ResolveDir(path)
+	if err != nil {
+		return ""
+	}
+	if _, ok := fsys.(vfs.Local); ok {
+		return path
+	}
+	for p := path; p != vfs.Dir(p); p = vfs.Dir(p) {
+		if localDir(p) {
+			return p
+		}
+	}
+	return ""
+}
+
+// openRemoteFile copies a file of another backend to a temporary directory
+// to open it. The copies are kept until the exit, the applications opening
+// them may run in the background.
+func (m *model) openRemoteFile(path string) (tea.Cmd, error) {
+	if m.tempDir == "" {
+		dir, err := os.MkdirTemp("", "gommander-")
+		if err != nil {
+			return nil, fmt.Errorf("error creating temporary directory: %v", err)
+		}
+		m.tempDir = dir
+	}
+	tmp, err := os.MkdirTemp(m.tempDir, "")
+	if err != nil {
+		return nil, fmt.Errorf("error creating temporary directory: %v", err)
+	}
+	if err := vfs.Copy(path, tmp, true); err != nil {
+		return nil, fmt.Errorf("error copying %v: %v", path, err)
+	}
+	return m.openFile(vfs.Join(tmp, vfs.Base(path)))
+}
+
+// RemoveTempFiles removes the copies of the files of other backends opened
+// during the session.
+func RemoveTempFiles(tm tea.Model) error {
+	m, ok := tm.(model)
+	if !ok || m.tempDir == "" {
+		return nil
+	}
+	return os.RemoveAll(m.tempDir)
+}
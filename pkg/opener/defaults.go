@@ -0,0 +1,64 @@
+package opener
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/sandrolain/gommander/pkg/state"
+)
+
+const defaultsFile = "openers.json"
+
+// Choice is an application chosen by the user to open a type of files.
+type Choice struct {
+	Label    string `json:"label"`
+	Command  string `json:"command"`
+	Terminal bool   `json:"terminal"`
+	// Desktop is set when Command is the Exec key of a desktop entry
+	Desktop bool `json:"desktop,omitempty"`
+}
+
+func (c Choice) Cmd(path string) (*exec.Cmd, error) {
+	if c.Desktop {
+		return DesktopEntry{Exec: c.Command}.Cmd(path)
+	}
+	return Command(c.Command, path)
+}
+
+// Defaults maps MIME types (or extensions when the type is unknown) to the
+// remembered choices.
+type Defaults map[string]Choice
+
+// TypeKey returns the key used to remember the choice for the file.
+func TypeKey(path string) string {
+	if t := DetectMIME(path); t != "" {
+		return t
+	}
+	return strings.ToLower(filepath.Ext(path))
+}
+
+func LoadDefaults() (Defaults, error) {
+	defaults := Defaults{}
+	if err := state.Load(defaultsFile, &defaults); err != nil {
+		return Defaults{}, err
+	}
+	// A file containing null leaves no map to add the choices to
+	if defaults == nil {
+		defaults = Defaults{}
+	}
+	return defaults, nil
+}
+
+func SaveDefaults(defaults Defaults) error {
+	return state.Save(defaultsFile, defaults)
+}
+
+func (d Defaults) Find(path string) (Choice, bool) {
+	key := TypeKey(path)
+	if key == "" {
+		return Choice{}, false
+	}
+	c, ok := d[key]
+	return c, ok
+}
@@ -0,0 +1,194 @@
+package opener
+
+import (
+	"bufio"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DesktopEntry is an application declared by a .desktop file.
+type DesktopEntry struct {
+	ID        string
+	Name      string
+	Exec      string
+	Terminal  bool
+	MimeTypes []string
+}
+
+// Cmd expands the field codes of the Exec key with the file path.
+func (e DesktopEntry) Cmd(path string) (*exec.Cmd, error) {
+	args, err := SplitArgs(e.Exec)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []string{}
+	hasFile := false
+	for _, arg := range args {
+		switch arg {
+		case "%f", "%F", "%u", "%U":
+			res = append(res, path)
+			hasFile = true
+			continue
+		case "%i", "%c", "%k", "%d", "%D", "%n", "%N", "%v", "%m":
+			continue
+		}
+		for _, code := range []string{"%f", "%F", "%u", "%U"} {
+			if strings.Contains(arg, code) {
+				arg = strings.ReplaceAll(arg, code, path)
+				hasFile = true
+			}
+		}
+		res = append(res, strings.ReplaceAll(arg, "%%", "%"))
+	}
+
+	if len(res) == 0 {
+		return nil, os.ErrInvalid
+	}
+	if !hasFile {
+		res = append(res, path)
+	}
+
+	return exec.Command(res[0], res[1:]...), nil
+}
+
+func (e DesktopEntry) Supports(mimeType string) bool {
+	for _, t := range e.MimeTypes {
+		if matchMIME(t, mimeType) {
+			return true
+		}
+	}
+	return false
+}
+
+// applicationDirs returns the XDG application directories ordered by
+// priority.
+func applicationDirs() []string {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			dataHome = filepath.Join(home, ".local", "share")
+		}
+	}
+
+	dataDirs := os.Getenv("XDG_DATA_DIRS")
+	if dataDirs == "" {
+		dataDirs = "/usr/local/share:/usr/share"
+	}
+
+	dirs := []string{}
+	if dataHome != "" {
+		dirs = append(dirs, filepath.Join(dataHome, "applications"))
+	}
+	for _, dir := range filepath.SplitList(dataDirs) {
+		dirs = append(dirs, filepath.Join(dir, "applications"))
+	}
+	return dirs
+}
+
+// DesktopEntries returns the applications declaring support for the MIME
+// type, sorted by name.
+func DesktopEntries(mimeType string) []DesktopEntry {
+	if mimeType == "" {
+		return nil
+	}
+
+	seen := map[string]bool{}
+	entries := []DesktopEntry{}
+
+	for _, dir := range applicationDirs() {
+		filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || filepath.Ext(path) != ".desktop" {
+				return nil
+			}
+
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return nil
+			}
+			id := strings.ReplaceAll(rel, string(filepath.Separator), "-")
+			if seen[id] {
+				return nil
+			}
+			// Entries in higher priority directories shadow the others,
+			// even when hidden
+			seen[id] = true
+
+			entry, ok := parseDesktopEntry(path)
+			if !ok || !entry.Supports(mimeType) {
+				return nil
+			}
+			entry.ID = id
+			entries = append(entries, entry)
+			return nil
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return strings.ToLower(entries[i].Name) < strings.ToLower(entries[j].Name)
+	})
+
+	return entries
+}
+
+func parseDesktopEntry(path string) (DesktopEntry, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return DesktopEntry{}, false
+	}
+	defer f.Close()
+
+	entry := DesktopEntry{}
+	inSection := false
+	hidden := false
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			inSection = line == "[Desktop Entry]"
+			continue
+		}
+		if !inSection {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "Name":
+			entry.Name = value
+		case "Exec":
+			entry.Exec = value
+		case "Terminal":
+			entry.Terminal = value == "true"
+		case "MimeType":
+			for _, t := range strings.Split(value, ";") {
+				if t != "" {
+					entry.MimeTypes = append(entry.MimeTypes, t)
+				}
+			}
+		case "Hidden":
+			hidden = value == "true"
+		case "Type":
+			hidden = hidden || value != "Application"
+		}
+	}
+
+	if hidden || entry.Exec == "" || entry.Name == "" {
+		return DesktopEntry{}, false
+	}
+	return entry, true
+}
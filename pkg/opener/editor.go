@@ -0,0 +1,31 @@
+package opener
+
+import (
+	"os"
+	"os/exec"
+)
+
+const fallbackEditor = "code"
+
+type Editor struct {
+	Command  string `toml:"command"`
+	Terminal bool   `toml:"terminal"`
+}
+
+// ResolveEditor returns the configured editor, falling back to $VISUAL and
+// $EDITOR (assumed to be terminal editors) and finally to VS Code.
+func ResolveEditor(configured Editor) Editor {
+	if configured.Command != "" {
+		return configured
+	}
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if cmd := os.Getenv(env); cmd != "" {
+			return Editor{Command: cmd, Terminal: true}
+		}
+	}
+	return Editor{Command: fallbackEditor}
+}
+
+func (e Editor) Cmd(path string) (*exec.Cmd, error) {
+	return Command(e.Command, path)
+}
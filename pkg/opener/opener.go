@@ -0,0 +1,195 @@
+package opener
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Rule maps files to the command used to open them. Each entry of Match
+// is either a file extension (".png"), a MIME type pattern ("image/*") or
+// a glob matched against the file name ("*.tar.gz", "Makefile").
+type Rule struct {
+	Match    []string `toml:"match"`
+	Command  string   `toml:"command"`
+	Terminal bool     `toml:"terminal"`
+}
+
+func (r Rule) Matches(path string, mimeType string) bool {
+	name := filepath.Base(path)
+	ext := strings.ToLower(filepath.Ext(path))
+
+	for _, pattern := range r.Match {
+		switch {
+		case strings.Contains(pattern, "/"):
+			if matchMIME(pattern, mimeType) {
+				return true
+			}
+		case strings.HasPrefix(pattern, ".") && !strings.ContainsAny(pattern, "*?["):
+			if strings.ToLower(pattern) == ext {
+				return true
+			}
+		default:
+			if ok, _ := filepath.Match(pattern, name); ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (r Rule) Cmd(path string) (*exec.Cmd, error) {
+	return Command(r.Command, path)
+}
+
+// Command builds the command for the file, replacing the placeholders in
+// the arguments: {path}, {dir}, {name} (file name), {base} (file name
+// without extension) and {ext}. The path is appended if no placeholder is
+// used.
+func Command(command string, path string) (*exec.Cmd, error) {
+	args, err := SplitArgs(command)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty command")
+	}
+
+	name := filepath.Base(path)
+	replacer := strings.NewReplacer(
+		"{path}", path,
+		"{dir}", filepath.Dir(path),
+		"{name}", name,
+		"{base}", strings.TrimSuffix(name, filepath.Ext(name)),
+		"{ext}", strings.TrimPrefix(filepath.Ext(name), "."),
+	)
+
+	replaced := false
+	for i, arg := range args {
+		args[i] = replacer.Replace(arg)
+		replaced = replaced || args[i] != arg
+	}
+	if !replaced {
+		args = append(args, path)
+	}
+
+	return exec.Command(args[0], args[1:]...), nil
+}
+
+func Find(rules []Rule, path string) (Rule, bool) {
+	mimeType := DetectMIME(path)
+	for _, rule := range rules {
+		if rule.Matches(path, mimeType) {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// Default returns the command opening the file with the default
+// application of the operating system.
+func Default(path string) *exec.Cmd {
+	switch runtime.GOOS {
+	case "windows":
+		return exec.Command("cmd", "/c", "start", path)
+	case "darwin":
+		return exec.Command("open", path)
+	default: // Assume Linux or other Unix-like OS
+		return exec.Command("xdg-open", path)
+	}
+}
+
+func DetectMIME(path string) string {
+	if t := mime.TypeByExtension(filepath.Ext(path)); t != "" {
+		mediaType, _, err := mime.ParseMediaType(t)
+		if err == nil {
+			return mediaType
+		}
+		return t
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	buf := make([]byte, 512)
+	n, _ := f.Read(buf)
+	if n == 0 {
+		return ""
+	}
+
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(buf[:n]))
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
+
+func matchMIME(pattern string, mimeType string) bool {
+	if mimeType == "" {
+		return false
+	}
+	if ok, _ := filepath.Match(strings.ToLower(pattern), strings.ToLower(mimeType)); ok {
+		return true
+	}
+	return false
+}
+
+// SplitArgs splits a command line into arguments, honouring single and
+// double quotes and backslash escapes.
+func SplitArgs(s string) ([]string, error) {
+	args := []string{}
+	var current strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in %q", s)
+	}
+	if escaped {
+		return nil, fmt.Errorf("trailing backslash in %q", s)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+
+	return args, nil
+}
@@ -0,0 +1,169 @@
+package preview
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sandrolain/gommander/pkg/vfs"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+type Protocol int
+
+const (
+	ProtocolBlocks Protocol = iota
+	ProtocolKitty
+	ProtocolSixel
+)
+
+const (
+	defaultCellWidth  = 10
+	defaultCellHeight = 20
+)
+
+var imageExtensions = map[string]bool{
+	".png":  true,
+	".jpg":  true,
+	".jpeg": true,
+	".gif":  true,
+	".webp": true,
+}
+
+func IsImage(path string) bool {
+	return imageExtensions[strings.ToLower(filepath.Ext(path))]
+}
+
+// DetectProtocol guesses the best graphics protocol supported by the
+// terminal from the environment, falling back to Unicode half blocks.
+func DetectProtocol() Protocol {
+	term := os.Getenv("TERM")
+	termProgram := os.Getenv("TERM_PROGRAM")
+
+	switch {
+	case os.Getenv("KITTY_WINDOW_ID") != "",
+		term == "xterm-kitty",
+		term == "xterm-ghostty",
+		termProgram == "ghostty":
+		return ProtocolKitty
+	case strings.Contains(term, "sixel"),
+		strings.HasPrefix(term, "foot"),
+		strings.HasPrefix(term, "mlterm"),
+		strings.HasPrefix(term, "yaft"),
+		termProgram == "WezTerm",
+		termProgram == "iTerm.app",
+		termProgram == "contour":
+		return ProtocolSixel
+	}
+
+	return ProtocolBlocks
+}
+
+var protocol = DetectProtocol()
+
+func decodeImage(fsys vfs.FS, path string) (image.Image, error) {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+func renderImage(fsys vfs.FS, path string, width int, height int) (string, string, error) {
+	img, err := decodeImage(fsys, path)
+	if err != nil {
+		return "", "", err
+	}
+
+	b := img.Bounds()
+	title := fmt.Sprintf("%dx%d", b.Dx(), b.Dy())
+	lines := []string{title}
+	height--
+
+	if height <= 0 {
+		return FitLines(lines, width, height+1), "", nil
+	}
+
+	cellW, cellH := cellSize()
+	cols, rows := fitCells(b.Dx(), b.Dy(), width, height, cellW, cellH)
+
+	switch protocol {
+	case ProtocolKitty:
+		return FitLines(append(lines, kittyLines(img, cols, rows, cellW, cellH)...), width, height+1), "", nil
+	case ProtocolSixel:
+		overlay := sixelOverlay(img, cols*cellW, rows*cellH, height-1, width)
+		return FitLines(lines, width, height+1), overlay, nil
+	}
+
+	return FitLines(append(lines, halfBlockLines(img, cols, rows)...), width, height+1), "", nil
+}
+
+// fitCells returns the number of terminal cells that fit the image into
+// the given area preserving its aspect ratio.
+func fitCells(imgW int, imgH int, width int, height int, cellW int, cellH int) (int, int) {
+	if imgW <= 0 || imgH <= 0 {
+		return 0, 0
+	}
+
+	pxW := width * cellW
+	pxH := height * cellH
+
+	if imgW*pxH > imgH*pxW {
+		pxH = imgH * pxW / imgW
+	} else {
+		pxW = imgW * pxH / imgH
+	}
+
+	cols := max(1, pxW/cellW)
+	rows := max(1, pxH/cellH)
+	return min(cols, width), min(rows, height)
+}
+
+func scaleImage(img image.Image, w int, h int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.ApproxBiLinear.Scale(dst, dst.Bounds(), img, img.Bounds(), draw.Over, nil)
+	return dst
+}
+
+// halfBlockLines renders two pixels per cell using the upper half block
+// character, with the foreground as the upper pixel and the background as
+// the lower one.
+func halfBlockLines(img image.Image, cols int, rows int) []string {
+	if cols <= 0 || rows <= 0 {
+		return nil
+	}
+
+	scaled := scaleImage(img, cols, rows*2)
+	lines := make([]string, rows)
+
+	for y := 0; y < rows; y++ {
+		var sb strings.Builder
+		for x := 0; x < cols; x++ {
+			top := scaled.RGBAAt(x, y*2)
+			bottom := scaled.RGBAAt(x, y*2+1)
+			fmt.Fprintf(&sb, "\x1b[38;2;%d;%d;%dm\x1b[48;2;%d;%d;%dm▀", top.R, top.G, top.B, bottom.R, bottom.G, bottom.B)
+		}
+		sb.WriteString("\x1b[0m")
+		lines[y] = sb.String()
+	}
+
+	return lines
+}
+
+func toRGB(c color.Color) (uint8, uint8, uint8) {
+	r, g, b, _ := c.RGBA()
+	return uint8(r >> 8), uint8(g >> 8), uint8(b >> 8)
+}
@@ -0,0 +1,105 @@
+package preview
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+	"strings"
+)
+
+const (
+	kittyImageID   = 71
+	kittyChunkSize = 4096
+	kittyCellRune  = '\U0010EEEE'
+)
+
+// Diacritics used by the kitty Unicode placeholders to encode the row and
+// column of each cell, see rowcolumn-diacritics.txt in the kitty sources.
+var kittyDiacritics = []rune{
+	0x0305, 0x030D, 0x030E, 0x0310, 0x0312, 0x033D, 0x033E, 0x033F,
+	0x0346, 0x034A, 0x034B, 0x034C, 0x0350, 0x0351, 0x0352, 0x0357,
+	0x035B, 0x0363, 0x0364, 0x0365, 0x0366, 0x0367, 0x0368, 0x0369,
+	0x036A, 0x036B, 0x036C, 0x036D, 0x036E, 0x036F, 0x0483, 0x0484,
+	0x0485, 0x0486, 0x0487, 0x0592, 0x0593, 0x0594, 0x0595, 0x0597,
+	0x0598, 0x0599, 0x059C, 0x059D, 0x059E, 0x059F, 0x05A0, 0x05A1,
+	0x05A8, 0x05A9, 0x05AB, 0x05AC, 0x05AF, 0x05C4, 0x0610, 0x0611,
+	0x0612, 0x0613, 0x0614, 0x0615, 0x0616, 0x0617, 0x0657, 0x0658,
+	0x0659, 0x065A, 0x065B, 0x065D, 0x065E, 0x06D6, 0x06D7, 0x06D8,
+	0x06D9, 0x06DA, 0x06DB, 0x06DC, 0x06DF, 0x06E0, 0x06E1, 0x06E2,
+	0x06E4, 0x06E7, 0x06E8, 0x06EB, 0x06EC, 0x0730, 0x0732, 0x0733,
+	0x0735, 0x0736, 0x073A, 0x073D, 0x073F, 0x0740, 0x0741, 0x0743,
+	0x0745, 0x0747, 0x0749, 0x074A, 0x07EB, 0x07EC, 0x07ED, 0x07EE,
+	0x07EF, 0x07F0, 0x07F1, 0x07F3, 0x0816, 0x0817, 0x0818, 0x0819,
+	0x081B, 0x081C, 0x081D, 0x081E, 0x081F, 0x0820, 0x0821, 0x0822,
+	0x0823, 0x0825, 0x0826, 0x0827, 0x0829, 0x082A, 0x082B, 0x082C,
+	0x082D, 0x0951, 0x0953, 0x0954, 0x0F82, 0x0F83, 0x0F86, 0x0F87,
+}
+
+// kittyLines transmits the image as a virtual placement and returns the
+// Unicode placeholder cells the terminal replaces with the image. Using
+// placeholders keeps the image anchored to the text grid, so it survives
+// the line based redraws of the TUI.
+func kittyLines(img image.Image, cols int, rows int, cellW int, cellH int) []string {
+	rows = min(rows, len(kittyDiacritics))
+	if cols <= 0 || rows <= 0 {
+		return nil
+	}
+
+	b := img.Bounds()
+	if b.Dx() > cols*cellW || b.Dy() > rows*cellH {
+		img = scaleImage(img, cols*cellW, rows*cellH)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return []string{fmt.Sprintf("Error encoding image: %v", err)}
+	}
+
+	transmit := kittyTransmit(buf.Bytes(), cols, rows)
+
+	lines := make([]string, rows)
+	for y := 0; y < rows; y++ {
+		var sb strings.Builder
+		if y == 0 {
+			sb.WriteString(transmit)
+		}
+		fmt.Fprintf(&sb, "\x1b[38;5;%dm", kittyImageID)
+		// Only the first cell needs the diacritics, the terminal infers
+		// the column of the following ones.
+		sb.WriteRune(kittyCellRune)
+		sb.WriteRune(kittyDiacritics[y])
+		sb.WriteRune(kittyDiacritics[0])
+		sb.WriteString(strings.Repeat(string(kittyCellRune), cols-1))
+		sb.WriteString("\x1b[39m")
+		lines[y] = sb.String()
+	}
+
+	return lines
+}
+
+func kittyTransmit(data []byte, cols int, rows int) string {
+	encoded := base64.StdEncoding.EncodeToString(data)
+
+	var sb strings.Builder
+	first := true
+	for len(encoded) > 0 {
+		chunk := encoded[:min(kittyChunkSize, len(encoded))]
+		encoded = encoded[len(chunk):]
+
+		more := 0
+		if len(encoded) > 0 {
+			more = 1
+		}
+
+		if first {
+			fmt.Fprintf(&sb, "\x1b_Ga=T,U=1,f=100,t=d,q=2,i=%d,c=%d,r=%d,m=%d;%s\x1b\\", kittyImageID, cols, rows, more, chunk)
+			first = false
+		} else {
+			fmt.Fprintf(&sb, "\x1b_Gm=%d;%s\x1b\\", more, chunk)
+		}
+	}
+
+	return sb.String()
+}
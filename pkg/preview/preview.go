@@ -0,0 +1,240 @@
+package preview
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/x/ansi"
+	humanize "github.com/dustin/go-humanize"
+	"github.com/sandrolain/gommander/pkg/vfs"
+)
+
+const (
+	maxTextBytes       = 64 * 1024
+	maxStructuredBytes = 1024 * 1024
+)
+
+type Preview struct {
+	Path    string
+	Width   int
+	Height  int
+	Content string
+	// Overlay is a zero width escape sequence that has to be written after
+	// the last line of Content (used by the sixel protocol).
+	Overlay string
+
+	lines  []string
+	offset int
+	tree   *tree
+}
+
+func Render(path string, width int, height int) Preview {
+	p := Preview{
+		Path:   path,
+		Width:  width,
+		Height: height,
+	}
+
+	if width <= 0 || height <= 0 {
+		return p
+	}
+
+	// The files are read through their backend, remote or in an archive
+	fsys, fpath, err := vfs.Resolve(path)
+	if err != nil {
+		p.setLines([]string{fmt.Sprintf("Error: %v", err)})
+		return p
+	}
+	info, err := fsys.Stat(fpath)
+	if err != nil {
+		p.setLines([]string{fmt.Sprintf("Error: %v", err)})
+		return p
+	}
+
+	if info.IsDir() {
+		p.setLines(renderDir(fsys, fpath))
+		return p
+	}
+
+	if IsImage(path) {
+		content, overlay, err := renderImage(fsys, fpath, width, height)
+		if err == nil {
+			p.Content = content
+			p.Overlay = overlay
+			return p
+		}
+		p.setLines([]string{fmt.Sprintf("Error decoding image: %v", err)})
+		return p
+	}
+
+	if format := structuredFormat(path); format != "" && info.Size() <= maxStructuredBytes {
+		data, err := readHead(fsys, fpath, maxStructuredBytes)
+		if err != nil {
+			p.setLines([]string{fmt.Sprintf("Error: %v", err)})
+			return p
+		}
+		p.renderStructured(format, data)
+		return p
+	}
+
+	p.setLines(renderFile(fsys, fpath, info))
+	return p
+}
+
+// Update handles the navigation keys when the preview pane has the focus,
+// named like the keys of the configuration ("space" for the space bar),
+// returning false if the key is not handled.
+func (p *Preview) Update(key string) bool {
+	switch key {
+	case "up", "k":
+		p.move(-1)
+	case "down", "j":
+		p.move(1)
+	case "pgup":
+		p.move(-p.Height)
+	case "pgdown":
+		p.move(p.Height)
+	case "home", "g":
+		p.move(-p.length())
+	case "end", "G":
+		p.move(p.length())
+	case "enter", "space":
+		if p.tree == nil {
+			return false
+		}
+		p.tree.toggle()
+	case "right", "l":
+		if p.tree == nil {
+			return false
+		}
+		p.tree.expand()
+	case "left", "h":
+		if p.tree == nil {
+			return false
+		}
+		p.tree.collapse()
+	default:
+		return false
+	}
+
+	p.layout()
+	return true
+}
+
+func (p *Preview) length() int {
+	if p.tree != nil {
+		return len(p.tree.visible())
+	}
+	return len(p.lines)
+}
+
+func (p *Preview) move(delta int) {
+	if p.tree != nil {
+		p.tree.move(delta)
+		return
+	}
+	p.offset = max(0, min(p.offset+delta, len(p.lines)-p.Height))
+}
+
+func (p *Preview) setLines(lines []string) {
+	p.lines = lines
+	p.offset = 0
+	p.layout()
+}
+
+func (p *Preview) layout() {
+	if p.tree != nil {
+		p.Content = FitLines(p.tree.render(p.Height), p.Width, p.Height)
+		return
+	}
+	p.Content = FitLines(p.lines[min(p.offset, len(p.lines)):], p.Width, p.Height)
+}
+
+func renderDir(fsys vfs.FS, path string) []string {
+	entries, err := fsys.ReadDir(path)
+	if err != nil {
+		return []string{fmt.Sprintf("Error: %v", err)}
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			name += string(filepath.Separator)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return append([]string{fmt.Sprintf("%d entries", len(entries)), ""}, names...)
+}
+
+func renderFile(fsys vfs.FS, path string, info fs.FileInfo) []string {
+	data, err := readHead(fsys, path, maxTextBytes)
+	if err != nil {
+		return []string{fmt.Sprintf("Error: %v", err)}
+	}
+
+	if isBinary(data) {
+		return []string{
+			"Binary file",
+			fmt.Sprintf("Size: %s", humanize.Bytes(uint64(info.Size()))),
+		}
+	}
+
+	return textLines(string(data))
+}
+
+func textLines(text string) []string {
+	text = strings.ReplaceAll(text, "\t", "    ")
+	return strings.Split(text, "\n")
+}
+
+func readHead(fsys vfs.FS, path string, limit int64) ([]byte, error) {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return io.ReadAll(io.LimitReader(f, limit))
+}
+
+func isBinary(data []byte) bool {
+	if bytes.IndexByte(data, 0) >= 0 {
+		return true
+	}
+	// The buffer may end in the middle of a multi-byte rune
+	for i := 0; i < utf8.UTFMax && len(data) > 0; i++ {
+		if utf8.Valid(data) {
+			return false
+		}
+		data = data[:len(data)-1]
+	}
+	return !utf8.Valid(data)
+}
+
+// FitLines truncates and pads the lines to fill exactly width x height cells.
+func FitLines(lines []string, width int, height int) string {
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	res := make([]string, height)
+	for i := range res {
+		line := ""
+		if i < len(lines) {
+			line = ansi.Truncate(strings.TrimRight(lines[i], "\r"), width, "…")
+		}
+		if w := ansi.StringWidth(line); w < width {
+			line += strings.Repeat(" ", width-w)
+		}
+		res[i] = line
+	}
+	return strings.Join(res, "\n")
+}
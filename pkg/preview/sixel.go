@@ -0,0 +1,98 @@
+package preview
+
+import (
+	"fmt"
+	"image"
+	"image/color/palette"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+// sixelOverlay returns the escape sequence that draws the image with its
+// top left corner up and left of the current cursor position, restoring
+// the cursor afterwards. It is meant to be written after the text of the
+// preview pane, otherwise the text would overwrite the image cells.
+func sixelOverlay(img image.Image, w int, h int, up int, left int) string {
+	if w <= 0 || h <= 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\x1b7")
+	if up > 0 {
+		fmt.Fprintf(&sb, "\x1b[%dA", up)
+	}
+	if left > 0 {
+		fmt.Fprintf(&sb, "\x1b[%dD", left)
+	}
+	sb.WriteString(encodeSixel(scaleImage(img, w, h)))
+	sb.WriteString("\x1b8")
+	return sb.String()
+}
+
+func encodeSixel(img image.Image) string {
+	b := img.Bounds()
+	paletted := image.NewPaletted(b, palette.Plan9)
+	draw.FloydSteinberg.Draw(paletted, b, img, b.Min)
+
+	var sb strings.Builder
+	sb.WriteString("\x1bP0;1;0q")
+	fmt.Fprintf(&sb, "\"1;1;%d;%d", b.Dx(), b.Dy())
+
+	for i, c := range paletted.Palette {
+		r, g, bl := toRGB(c)
+		fmt.Fprintf(&sb, "#%d;2;%d;%d;%d", i, int(r)*100/255, int(g)*100/255, int(bl)*100/255)
+	}
+
+	band := make([]byte, b.Dx())
+	for y := 0; y < b.Dy(); y += 6 {
+		used := map[uint8]bool{}
+		for dy := 0; dy < 6 && y+dy < b.Dy(); dy++ {
+			for x := 0; x < b.Dx(); x++ {
+				used[paletted.ColorIndexAt(b.Min.X+x, b.Min.Y+y+dy)] = true
+			}
+		}
+
+		first := true
+		for idx := 0; idx < len(paletted.Palette); idx++ {
+			if !used[uint8(idx)] {
+				continue
+			}
+			for x := 0; x < b.Dx(); x++ {
+				var bits byte
+				for dy := 0; dy < 6 && y+dy < b.Dy(); dy++ {
+					if paletted.ColorIndexAt(b.Min.X+x, b.Min.Y+y+dy) == uint8(idx) {
+						bits |= 1 << dy
+					}
+				}
+				band[x] = '?' + bits
+			}
+			if !first {
+				sb.WriteByte('$')
+			}
+			first = false
+			fmt.Fprintf(&sb, "#%d", idx)
+			writeSixelRLE(&sb, band)
+		}
+		sb.WriteByte('-')
+	}
+
+	sb.WriteString("\x1b\\")
+	return sb.String()
+}
+
+func writeSixelRLE(sb *strings.Builder, band []byte) {
+	for i := 0; i < len(band); {
+		j := i
+		for j < len(band) && band[j] == band[i] {
+			j++
+		}
+		if n := j - i; n > 3 {
+			fmt.Fprintf(sb, "!%d%c", n, band[i])
+		} else {
+			sb.Write(band[i:j])
+		}
+		i = j
+	}
+}
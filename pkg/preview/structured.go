@@ -0,0 +1,375 @@
+package preview
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sandrolain/gommander/pkg/theme"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	errorLineStyle lipgloss.Style
+	lineNumStyle   = lipgloss.NewStyle().Faint(true)
+	markdownStyle  string
+)
+
+func init() {
+	SetTheme(theme.Default())
+}
+
+// SetTheme colours the trees, the syntax errors and the Markdown of the
+// previews with the theme.
+func SetTheme(t theme.Theme) {
+	c := t.Resolve()
+	keyStyle = colorStyle(c.Key)
+	stringStyle = colorStyle(c.String)
+	numberStyle = colorStyle(c.Number)
+	literalStyle = colorStyle(c.Literal)
+	errorLineStyle = colorStyle(c.Error).Bold(true)
+
+	switch t.Preset {
+	case theme.PresetLight:
+		markdownStyle = "light"
+	case theme.PresetMono:
+		markdownStyle = "notty"
+	default:
+		markdownStyle = "dark"
+	}
+}
+
+// colorStyle returns a style with the foreground colour, the terminal
+// default one if empty.
+func colorStyle(c string) lipgloss.Style {
+	if c == "" {
+		return lipgloss.NewStyle()
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color(c))
+}
+
+var structuredExtensions = map[string]string{
+	".md":       "markdown",
+	".markdown": "markdown",
+	".json":     "json",
+	".yaml":     "yaml",
+	".yml":      "yaml",
+	".toml":     "toml",
+}
+
+func structuredFormat(path string) string {
+	return structuredExtensions[strings.ToLower(filepath.Ext(path))]
+}
+
+// syntaxError is a parse error with the position of the offending token.
+type syntaxError struct {
+	line int
+	col  int
+	msg  string
+}
+
+func (e *syntaxError) Error() string {
+	if e.col > 0 {
+		return fmt.Sprintf("line %d, column %d: %s", e.line, e.col, e.msg)
+	}
+	return fmt.Sprintf("line %d: %s", e.line, e.msg)
+}
+
+func (p *Preview) renderStructured(format string, data []byte) {
+	if format == "markdown" {
+		lines, err := renderMarkdown(data, p.Width)
+		if err != nil {
+			p.setLines(textLines(string(data)))
+			return
+		}
+		p.setLines(lines)
+		return
+	}
+
+	var root *node
+	var err error
+
+	switch format {
+	case "json":
+		root, err = parseJSON(data)
+	case "yaml":
+		root, err = parseYAML(data)
+	case "toml":
+		root, err = parseTOML(data)
+	}
+
+	if err != nil {
+		p.renderSyntaxError(data, err)
+		return
+	}
+
+	p.tree = newTree(root)
+	p.layout()
+}
+
+func renderMarkdown(data []byte, width int) ([]string, error) {
+	r, err := glamour.NewTermRenderer(
+		glamour.WithStandardStyle(markdownStyle),
+		glamour.WithWordWrap(width),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := r.RenderBytes(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.Split(strings.Trim(string(out), "\n"), "\n"), nil
+}
+
+// renderSyntaxError shows the source with line numbers and the error
+// message right below the offending line.
+func (p *Preview) renderSyntaxError(data []byte, err error) {
+	var se *syntaxError
+	if !errors.As(err, &se) {
+		p.setLines(append([]string{errorLineStyle.Render(err.Error()), ""}, textLines(string(data))...))
+		return
+	}
+
+	src := textLines(string(data))
+	lines := make([]string, 0, len(src)+1)
+	for i, line := range src {
+		num := lineNumStyle.Render(fmt.Sprintf("%4d │ ", i+1))
+		if i+1 != se.line {
+			lines = append(lines, num+line)
+			continue
+		}
+		lines = append(lines, num+errorLineStyle.Render(line))
+		lines = append(lines, errorLineStyle.Render(errorMarker(se.col)+se.Error()))
+	}
+	if se.line > len(src) {
+		lines = append(lines, errorLineStyle.Render(errorMarker(se.col)+se.Error()))
+	}
+
+	p.setLines(lines)
+	p.offset = max(0, min(se.line-p.Height/2, len(lines)-p.Height))
+	p.layout()
+}
+
+func errorMarker(col int) string {
+	return strings.Repeat(" ", 7+max(0, col-1)) + "^ "
+}
+
+func offsetPosition(data []byte, offset int64) (int, int) {
+	offset = min(offset, int64(len(data)))
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	col := int(offset) - (bytes.LastIndexByte(before, '\n') + 1)
+	return line, col
+}
+
+func parseJSON(data []byte) (*node, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	root, err := decodeJSONValue(dec, "")
+	if err == nil {
+		if _, err = dec.Token(); err == io.EOF {
+			return root, nil
+		}
+		if err == nil {
+			err = errors.New("unexpected data after top-level value")
+		}
+	}
+
+	var jsonErr *json.SyntaxError
+	offset := dec.InputOffset()
+	if errors.As(err, &jsonErr) {
+		offset = jsonErr.Offset
+	} else if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		offset = int64(len(data))
+		err = errors.New("unexpected end of JSON input")
+	}
+
+	line, col := offsetPosition(data, offset)
+	return nil, &syntaxError{line: line, col: col, msg: err.Error()}
+}
+
+func decodeJSONValue(dec *json.Decoder, key string) (*node, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		n := &node{key: key, kind: kindObject}
+		if t == '[' {
+			n.kind = kindArray
+		}
+		for i := 0; dec.More(); i++ {
+			childKey := strconv.Itoa(i)
+			if n.kind == kindObject {
+				kt, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				childKey, _ = kt.(string)
+			}
+			child, err := decodeJSONValue(dec, childKey)
+			if err != nil {
+				return nil, err
+			}
+			n.add(child)
+		}
+		// Closing delimiter
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return n, nil
+	case string:
+		return &node{key: key, kind: kindString, value: strconv.Quote(t)}, nil
+	case json.Number:
+		return &node{key: key, kind: kindNumber, value: t.String()}, nil
+	case bool:
+		return &node{key: key, kind: kindBool, value: strconv.FormatBool(t)}, nil
+	}
+
+	return &node{key: key, kind: kindNull, value: "null"}, nil
+}
+
+var yamlLineRegexp = regexp.MustCompile(`line (\d+)`)
+
+func parseYAML(data []byte) (*node, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+
+	docs := []*node{}
+	for {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			msg := strings.TrimPrefix(err.Error(), "yaml: ")
+			if m := yamlLineRegexp.FindStringSubmatch(msg); m != nil {
+				line, _ := strconv.Atoi(m[1])
+				msg = strings.TrimPrefix(msg, m[0]+": ")
+				return nil, &syntaxError{line: line, msg: msg}
+			}
+			return nil, err
+		}
+		docs = append(docs, yamlNode(&doc, ""))
+	}
+
+	if len(docs) == 1 {
+		return docs[0], nil
+	}
+
+	root := &node{kind: kindArray}
+	for i, doc := range docs {
+		doc.key = fmt.Sprintf("--- %d", i)
+		root.add(doc)
+	}
+	return root, nil
+}
+
+func yamlNode(yn *yaml.Node, key string) *node {
+	switch yn.Kind {
+	case yaml.DocumentNode:
+		if len(yn.Content) == 0 {
+			return &node{key: key, kind: kindNull, value: "null"}
+		}
+		return yamlNode(yn.Content[0], key)
+	case yaml.MappingNode:
+		n := &node{key: key, kind: kindObject}
+		for i := 0; i+1 < len(yn.Content); i += 2 {
+			n.add(yamlNode(yn.Content[i+1], yn.Content[i].Value))
+		}
+		return n
+	case yaml.SequenceNode:
+		n := &node{key: key, kind: kindArray}
+		for i, item := range yn.Content {
+			n.add(yamlNode(item, strconv.Itoa(i)))
+		}
+		return n
+	case yaml.AliasNode:
+		return &node{key: key, kind: kindString, value: "*" + yn.Value}
+	}
+
+	switch yn.ShortTag() {
+	case "!!int", "!!float":
+		return &node{key: key, kind: kindNumber, value: yn.Value}
+	case "!!bool":
+		return &node{key: key, kind: kindBool, value: yn.Value}
+	case "!!null":
+		return &node{key: key, kind: kindNull, value: "null"}
+	}
+	return &node{key: key, kind: kindString, value: strconv.Quote(yn.Value)}
+}
+
+func parseTOML(data []byte) (*node, error) {
+	var values map[string]any
+	meta, err := toml.Decode(string(data), &values)
+	if err != nil {
+		var pe toml.ParseError
+		if errors.As(err, &pe) {
+			return nil, &syntaxError{line: pe.Position.Line, col: pe.Position.Col, msg: pe.Message}
+		}
+		return nil, err
+	}
+
+	// Maps lose the definition order, restore it from the metadata
+	order := map[string]int{}
+	for i, key := range meta.Keys() {
+		order[strings.Join(key, "\x00")] = i
+	}
+
+	return tomlNode(values, "", "", order), nil
+}
+
+func tomlNode(value any, key string, path string, order map[string]int) *node {
+	switch v := value.(type) {
+	case map[string]any:
+		n := &node{key: key, kind: kindObject}
+		for k, child := range v {
+			n.add(tomlNode(child, k, joinKey(path, k), order))
+		}
+		n.sortChildren(func(a *node, b *node) bool {
+			return order[joinKey(path, a.key)] < order[joinKey(path, b.key)]
+		})
+		return n
+	case []map[string]any:
+		n := &node{key: key, kind: kindArray}
+		for i, item := range v {
+			n.add(tomlNode(item, strconv.Itoa(i), path, order))
+		}
+		return n
+	case []any:
+		n := &node{key: key, kind: kindArray}
+		for i, item := range v {
+			n.add(tomlNode(item, strconv.Itoa(i), path, order))
+		}
+		return n
+	case string:
+		return &node{key: key, kind: kindString, value: strconv.Quote(v)}
+	case int64, float64:
+		return &node{key: key, kind: kindNumber, value: fmt.Sprint(v)}
+	case bool:
+		return &node{key: key, kind: kindBool, value: strconv.FormatBool(v)}
+	}
+	return &node{key: key, kind: kindString, value: fmt.Sprint(value)}
+}
+
+func joinKey(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "\x00" + key
+}
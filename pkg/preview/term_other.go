@@ -0,0 +1,8 @@
+//go:build windows
+
+package preview
+
+// cellSize returns the size in pixels of a terminal cell.
+func cellSize() (int, int) {
+	return defaultCellWidth, defaultCellHeight
+}
@@ -0,0 +1,228 @@
+package preview
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Containers deeper than this are collapsed when the tree is created.
+const expandDepth = 2
+
+type nodeKind int
+
+const (
+	kindObject nodeKind = iota
+	kindArray
+	kindString
+	kindNumber
+	kindBool
+	kindNull
+)
+
+var (
+	keyStyle     lipgloss.Style
+	stringStyle  lipgloss.Style
+	numberStyle  lipgloss.Style
+	literalStyle lipgloss.Style
+	summaryStyle = lipgloss.NewStyle().Faint(true)
+	cursorStyle  = lipgloss.NewStyle().Reverse(true)
+)
+
+type node struct {
+	key       string
+	value     string
+	kind      nodeKind
+	children  []*node
+	collapsed bool
+	depth     int
+	parent    *node
+}
+
+func (n *node) add(child *node) {
+	child.parent = n
+	n.children = append(n.children, child)
+}
+
+func (n *node) sortChildren(less func(*node, *node) bool) {
+	sort.SliceStable(n.children, func(i, j int) bool {
+		return less(n.children[i], n.children[j])
+	})
+}
+
+func (n *node) isContainer() bool {
+	return n.kind == kindObject || n.kind == kindArray
+}
+
+func (n *node) summary() string {
+	if n.kind == kindObject {
+		if n.collapsed {
+			return fmt.Sprintf("{…} %d keys", len(n.children))
+		}
+		return fmt.Sprintf("{%d}", len(n.children))
+	}
+	if n.collapsed {
+		return fmt.Sprintf("[…] %d items", len(n.children))
+	}
+	return fmt.Sprintf("[%d]", len(n.children))
+}
+
+func (n *node) text(styled bool) string {
+	marker := "  "
+	if n.isContainer() {
+		marker = "▾ "
+		if n.collapsed {
+			marker = "▸ "
+		}
+	}
+
+	key := ""
+	if n.key != "" {
+		key = n.key + ": "
+		if styled {
+			key = keyStyle.Render(n.key) + ": "
+		}
+	}
+
+	value := n.value
+	if n.isContainer() {
+		value = n.summary()
+	}
+
+	if styled {
+		switch n.kind {
+		case kindObject, kindArray:
+			value = summaryStyle.Render(value)
+		case kindString:
+			value = stringStyle.Render(value)
+		case kindNumber:
+			value = numberStyle.Render(value)
+		default:
+			value = literalStyle.Render(value)
+		}
+	}
+
+	return strings.Repeat("  ", n.depth) + marker + key + value
+}
+
+type tree struct {
+	root   *node
+	cursor int
+	top    int
+}
+
+func newTree(root *node) *tree {
+	var setup func(n *node, depth int)
+	setup = func(n *node, depth int) {
+		n.depth = depth
+		n.collapsed = n.isContainer() && depth >= expandDepth && len(n.children) > 0
+		for _, child := range n.children {
+			setup(child, depth+1)
+		}
+	}
+
+	// The root container is implicit, its children are shown at depth 0
+	if root.isContainer() {
+		for _, child := range root.children {
+			setup(child, 0)
+		}
+	} else {
+		setup(root, 0)
+	}
+
+	return &tree{root: root}
+}
+
+func (t *tree) visible() []*node {
+	nodes := []*node{}
+	var walk func(n *node)
+	walk = func(n *node) {
+		nodes = append(nodes, n)
+		if n.collapsed {
+			return
+		}
+		for _, child := range n.children {
+			walk(child)
+		}
+	}
+
+	if !t.root.isContainer() {
+		return []*node{t.root}
+	}
+	for _, child := range t.root.children {
+		walk(child)
+	}
+	return nodes
+}
+
+func (t *tree) current() *node {
+	nodes := t.visible()
+	if t.cursor < 0 || t.cursor >= len(nodes) {
+		return nil
+	}
+	return nodes[t.cursor]
+}
+
+func (t *tree) move(delta int) {
+	t.cursor = max(0, min(t.cursor+delta, len(t.visible())-1))
+}
+
+func (t *tree) toggle() {
+	n := t.current()
+	if n != nil && n.isContainer() {
+		n.collapsed = !n.collapsed
+	}
+}
+
+func (t *tree) expand() {
+	n := t.current()
+	if n != nil && n.isContainer() {
+		n.collapsed = false
+	}
+}
+
+// collapse closes the current container, or moves the cursor to the
+// parent if it is already closed.
+func (t *tree) collapse() {
+	n := t.current()
+	if n == nil {
+		return
+	}
+	if n.isContainer() && !n.collapsed {
+		n.collapsed = true
+		return
+	}
+	if n.parent == nil || n.parent == t.root {
+		return
+	}
+	for i, v := range t.visible() {
+		if v == n.parent {
+			t.cursor = i
+			return
+		}
+	}
+}
+
+func (t *tree) render(height int) []string {
+	nodes := t.visible()
+
+	if t.cursor < t.top {
+		t.top = t.cursor
+	}
+	if t.cursor >= t.top+height {
+		t.top = t.cursor - height + 1
+	}
+	t.top = max(0, min(t.top, len(nodes)-height))
+
+	lines := []string{}
+	for i := t.top; i < len(nodes) && i < t.top+height; i++ {
+		if i == t.cursor {
+			lines = append(lines, cursorStyle.Render(nodes[i].text(false)))
+			continue
+		}
+		lines = append(lines, nodes[i].text(true))
+	}
+	return lines
+}
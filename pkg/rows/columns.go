@@ -0,0 +1,53 @@
+package rows
+
+import (
+	"github.com/charmbracelet/lipgloss"
+)
+
+type ColumnDef struct {
+	Key   string
+	Title string
+	Width int
+	// Flex columns share the width left by the others, Width is the flex factor
+	Flex  bool
+	Align lipgloss.Position
+}
+
+var ColumnDefs = []ColumnDef{
+	{Key: "icon", Title: "", Width: 2, Align: lipgloss.Center},
+	{Key: "name", Title: "Name", Width: 10, Flex: true, Align: lipgloss.Left},
+	{Key: "size", Title: "Size", Width: 8, Align: lipgloss.Right},
+	{Key: "mode", Title: "Mode", Width: 10, Align: lipgloss.Center},
+	{Key: "modified", Title: "Modified", Width: 19, Align: lipgloss.Center},
+	{Key: "owner", Title: "Owner", Width: 8, Align: lipgloss.Left},
+	{Key: "group", Title: "Group", Width: 8, Align: lipgloss.Left},
+	{Key: "octal", Title: "Octal", Width: 5, Align: lipgloss.Center},
+	{Key: "inode", Title: "Inode", Width: 10, Align: lipgloss.Right},
+	{Key: "nlink", Title: "Links", Width: 5, Align: lipgloss.Right},
+	{Key: "created", Title: "Created", Width: 19, Align: lipgloss.Center},
+	{Key: "accessed", Title: "Accessed", Width: 19, Align: lipgloss.Center},
+	{Key: "ext", Title: "Ext", Width: 6, Align: lipgloss.Left},
+	{Key: "mime", Title: "MIME type", Width: 18, Align: lipgloss.Left},
+	{Key: "git", Title: "Git", Width: 3, Align: lipgloss.Center},
+	{Key: "dirsize", Title: "Total size", Width: 10, Align: lipgloss.Right},
+	{Key: "compare", Title: "Cmp", Width: 3, Align: lipgloss.Center},
+}
+
+var DefaultColumns = []string{"name", "size", "mode", "modified"}
+
+func FindColumn(key string) (ColumnDef, bool) {
+	for _, def := range ColumnDefs {
+		if def.Key == key {
+			return def, true
+		}
+	}
+	return ColumnDef{}, false
+}
+
+func ColumnKeys() []string {
+	keys := make([]string, len(ColumnDefs))
+	for i, def := range ColumnDefs {
+		keys[i] = def.Key
+	}
+	return keys
+}
@@ -0,0 +1,74 @@
+package rows
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/evertras/bubble-table/table"
+	"github.com/sandrolain/gommander/pkg/opener"
+)
+
+const timeFormat = "2006-01-02 15:04:05"
+
+func fileExt(name string, isDir bool) string {
+	ext := filepath.Ext(name)
+	// Hidden files like .bashrc have no extension
+	if isDir || ext == name {
+		return ""
+	}
+	return strings.TrimPrefix(ext, ".")
+}
+
+// addExtraData fills the data of the optional columns that are visible.
+func addExtraData(data table.RowData, path string, info os.FileInfo, isDir bool, opts Options) {
+	if !opts.remote && (opts.shows("owner") || opts.shows("group") || opts.shows("inode") ||
+		opts.shows("nlink") || opts.shows("created") || opts.shows("accessed")) {
+		st := statFile(path, info)
+		data["owner"] = userName(st.uid)
+		data["group"] = groupName(st.gid)
+		data["inode"] = formatUint(st.inode)
+		data["uinode"] = st.inode
+		data["nlink"] = formatUint(st.nlink)
+		data["unlink"] = st.nlink
+		data["created"] = opts.Format.FormatTime(st.created)
+		data["ucreated"] = st.created
+		data["accessed"] = opts.Format.FormatTime(st.accessed)
+		data["uaccessed"] = st.accessed
+	}
+
+	data["octal"] = fmt.Sprintf("%04o", info.Mode().Perm()|octalSpecial(info.Mode()))
+
+	if opts.shows("mime") && !isDir && !opts.remote {
+		data["mime"] = opener.DetectMIME(path)
+	}
+
+	if opts.shows("dirsize") {
+		size := uint64(info.Size())
+		ok := !isDir
+		if isDir {
+			size, ok = opts.dirSize(path, data["name"].(string), info)
+		}
+		if ok {
+			data["dirsize"] = opts.Format.FormatSize(size)
+			data["udirsize"] = size
+		}
+	}
+}
+
+// octalSpecial returns the setuid, setgid and sticky bits in the
+// traditional octal positions.
+func octalSpecial(mode os.FileMode) os.FileMode {
+	var bits os.FileMode
+	if mode&os.ModeSetuid != 0 {
+		bits |= 0o4000
+	}
+	if mode&os.ModeSetgid != 0 {
+		bits |= 0o2000
+	}
+	if mode&os.ModeSticky != 0 {
+		bits |= 0o1000
+	}
+	return bits
+}
@@ -0,0 +1,37 @@
+package rows
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/evertras/bubble-table/table"
+)
+
+// MatchFilter reports whether the name matches the filter, a glob pattern
+// when it has wildcards and a part of the name otherwise, ignoring the case.
+func MatchFilter(name string, filter string) bool {
+	name, filter = strings.ToLower(name), strings.ToLower(filter)
+	if strings.ContainsAny(filter, "*?[") {
+		ok, err := filepath.Match(filter, name)
+		return ok && err == nil
+	}
+	return strings.Contains(name, filter)
+}
+
+// ValidFilter reports whether the wildcards of the filter are well formed.
+func ValidFilter(filter string) bool {
+	_, err := filepath.Match(filter, "")
+	return err == nil
+}
+
+// filterRows keeps the rows matching the filter and the parent directory.
+func filterRows(rows []table.Row, filter string) []table.Row {
+	kept := []table.Row{}
+	for _, row := range rows {
+		name, _ := row.Data["name"].(string)
+		if name == ".." || MatchFilter(name, filter) {
+			kept = append(kept, row)
+		}
+	}
+	return kept
+}
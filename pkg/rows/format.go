@@ -0,0 +1,131 @@
+package rows
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+)
+
+const (
+	TimeDefault  = "default"
+	TimeRelative = "relative"
+	TimeISO      = "iso"
+	TimeLocale   = "locale"
+)
+
+var TimeFormats = []string{TimeDefault, TimeRelative, TimeISO, TimeLocale}
+
+const (
+	SizeSI    = "si"
+	SizeIEC   = "iec"
+	SizeBytes = "bytes"
+)
+
+var SizeFormats = []string{SizeSI, SizeIEC, SizeBytes}
+
+// FormatOptions selects how times and sizes are displayed, the rows keep
+// the raw values for sorting. Time can also be a Go time layout.
+type FormatOptions struct {
+	Time string `toml:"time"`
+	Size string `toml:"size"`
+}
+
+func DefaultFormatOptions() FormatOptions {
+	return FormatOptions{Time: TimeDefault, Size: SizeSI}
+}
+
+var layoutReference = time.Date(2006, time.January, 2, 15, 4, 5, 0, time.UTC)
+
+func ValidTimeFormat(format string) bool {
+	for _, f := range TimeFormats {
+		if f == format {
+			return true
+		}
+	}
+	// A layout without any element would print itself
+	return format != "" && layoutReference.Format(format) != format
+}
+
+func ValidSizeFormat(format string) bool {
+	for _, f := range SizeFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+func (f FormatOptions) FormatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	switch f.Time {
+	case TimeDefault, "":
+		return t.Format(timeFormat)
+	case TimeRelative:
+		return humanize.Time(t)
+	case TimeISO:
+		return t.Format("2006-01-02T15:04:05")
+	case TimeLocale:
+		return t.Format(localeLayout())
+	}
+	return t.Format(f.Time)
+}
+
+func (f FormatOptions) FormatSize(size uint64) string {
+	switch f.Size {
+	case SizeIEC:
+		return humanize.IBytes(size)
+	case SizeBytes:
+		return humanize.Comma(int64(size))
+	}
+	return humanize.Bytes(size)
+}
+
+// Date layouts by language and territory, the language alone is used when
+// the territory is not listed.
+var localeLayouts = map[string]string{
+	"en_US": "01/02/2006 03:04 PM",
+	"en_CA": "2006-01-02 3:04 PM",
+	"en":    "02/01/2006 15:04",
+	"de":    "02.01.2006 15:04",
+	"ru":    "02.01.2006 15:04",
+	"pl":    "02.01.2006 15:04",
+	"fi":    "02.01.2006 15:04",
+	"nl":    "02-01-2006 15:04",
+	"sv":    "2006-01-02 15:04",
+	"ja":    "2006/01/02 15:04",
+	"zh":    "2006/01/02 15:04",
+	"ko":    "2006. 01. 02. 15:04",
+	"hu":    "2006. 01. 02. 15:04",
+}
+
+// localeLayout returns the date layout of the LC_ALL, LC_TIME or LANG
+// locale, defaulting to day/month/year used by most locales.
+func localeLayout() string {
+	locale := ""
+	for _, name := range []string{"LC_ALL", "LC_TIME", "LANG"} {
+		if locale = os.Getenv(name); locale != "" {
+			break
+		}
+	}
+
+	// Strip the encoding and the modifier, e.g. it_IT.UTF-8@euro
+	locale, _, _ = strings.Cut(locale, ".")
+	locale, _, _ = strings.Cut(locale, "@")
+	lang, _, _ := strings.Cut(locale, "_")
+
+	if layout, ok := localeLayouts[locale]; ok {
+		return layout
+	}
+	if layout, ok := localeLayouts[lang]; ok {
+		return layout
+	}
+	if locale == "" || locale == "C" || locale == "POSIX" {
+		return timeFormat
+	}
+	return "02/01/2006 15:04"
+}
@@ -0,0 +1,65 @@
+package rows
+
+import (
+	"bytes"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// gitStatus returns the short status (e.g. "M", "??", "A") of the entries
+// of dir that have changes, keyed by name. Directories get the status of
+// the first changed file they contain. It returns nil outside a repository
+// or if git is not installed.
+func gitStatus(dir string) map[string]string {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return nil
+	}
+	root := strings.TrimSpace(string(out))
+
+	// Paths in the porcelain format are relative to the repository root
+	rel, err := filepath.Rel(root, dir)
+	if err != nil {
+		return nil
+	}
+	if evaluated, err := filepath.EvalSymlinks(dir); err == nil {
+		if r, err := filepath.Rel(root, evaluated); err == nil {
+			rel = r
+		}
+	}
+	prefix := ""
+	if rel != "." {
+		prefix = filepath.ToSlash(rel) + "/"
+	}
+
+	out, err = exec.Command("git", "-C", dir, "status", "--porcelain", "-z", "--untracked-files=normal", "--", ".").Output()
+	if err != nil {
+		return nil
+	}
+
+	res := map[string]string{}
+	entries := bytes.Split(out, []byte{0})
+	for i := 0; i < len(entries); i++ {
+		entry := string(entries[i])
+		if len(entry) < 4 {
+			continue
+		}
+		status := strings.TrimSpace(entry[:2])
+		path := entry[3:]
+		// Renames and copies are followed by the original path
+		if entry[0] == 'R' || entry[0] == 'C' {
+			i++
+		}
+
+		path, ok := strings.CutPrefix(path, prefix)
+		if !ok {
+			continue
+		}
+		name, _, _ := strings.Cut(strings.TrimSuffix(path, "/"), "/")
+		if _, ok := res[name]; !ok {
+			res[name] = status
+		}
+	}
+	return res
+}
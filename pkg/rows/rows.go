@@ -2,15 +2,17 @@ package rows
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
-	"sort"
+	"time"
 
-	humanize "github.com/dustin/go-humanize"
 	"github.com/evertras/bubble-table/table"
+	"github.com/sandrolain/gommander/pkg/icons"
+	"github.com/sandrolain/gommander/pkg/vfs"
 )
 
-func getTableRowForPath(dirPath string, name string) (table.Row, error) {
+func getTableRowForPath(dirPath string, name string, opts Options) (table.Row, error) {
 	path := filepath.Join(dirPath, name)
 	path, err := filepath.Abs(path)
 	if err != nil {
@@ -22,26 +24,48 @@ func getTableRowForPath(dirPath string, name string) (table.Row, error) {
 		return table.Row{}, err
 	}
 
+	// Keep the mode of the link itself, used to colour the symlinks
+	mode := info.Mode()
+	if linfo, err := os.Lstat(path); err == nil {
+		mode = linfo.Mode()
+	}
+
+	return newTableRow(path, name, info, mode, opts), nil
+}
+
+func newTableRow(path string, name string, info os.FileInfo, mode fs.FileMode, opts Options) table.Row {
 	isDir := info.IsDir()
 	permissions := info.Mode().String()
 	usize := uint64(info.Size())
 
 	formattedSize := ""
 	if !isDir {
-		formattedSize = humanize.Bytes(usize)
+		formattedSize = opts.Format.FormatSize(usize)
+	} else {
+		// Directories show the computed size, when available
+		usize = 0
+		if size, ok := opts.dirSize(path, name, info); ok {
+			usize = size
+			formattedSize = opts.Format.FormatSize(size)
+		}
 	}
 
 	row := table.NewRow(table.RowData(map[string]interface{}{
-		"path":     path,
-		"dir":      isDir,
-		"name":     name,
-		"size":     formattedSize,
-		"usize":    usize,
-		"mode":     permissions,
-		"modified": info.ModTime().Format("2006-01-02 15:04:05"),
+		"path":      path,
+		"dir":       isDir,
+		"name":      name,
+		"size":      formattedSize,
+		"usize":     usize,
+		"mode":      permissions,
+		"umode":     mode,
+		"modified":  opts.Format.FormatTime(info.ModTime()),
+		"umodified": info.ModTime(),
+		"ext":       fileExt(name, isDir),
 	}))
 
-	return row, nil
+	addExtraData(row.Data, path, info, isDir, opts)
+
+	return row
 }
 
 type FilesInfo struct {
@@ -50,12 +74,56 @@ type FilesInfo struct {
 	Files int
 }
 
-func GetTableRows(dir string) (FilesInfo, []table.Row) {
+type Options struct {
+	Sort   SortOptions
+	Format FormatOptions
+	// Columns lists the keys of the visible columns, the data of the
+	// expensive ones is only collected when they are shown
+	Columns []string
+	// Icons fills the icon column, nil when it is not shown
+	Icons *icons.Set
+	// DirSizes returns the computed size of a directory
+	DirSizes func(path string, modTime time.Time) (uint64, bool)
+	// Filter hides the entries whose name does not match, see MatchFilter
+	Filter string
+	// remote is set for the backends other than the local file system
+	remote bool
+}
+
+func (o Options) dirSize(path string, name string, info os.FileInfo) (uint64, bool) {
+	if o.DirSizes == nil || name == ".." {
+		return 0, false
+	}
+	return o.DirSizes(path, info.ModTime())
+}
+
+func (o Options) shows(key string) bool {
+	for _, k := range o.Columns {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+func DefaultOptions() Options {
+	return Options{Sort: DefaultSortOptions(), Format: DefaultFormatOptions()}
+}
+
+func GetTableRows(dir string, opts Options) (FilesInfo, []table.Row) {
+	fsys, fdir, err := vfs.ResolveDir(dir)
+	if err != nil {
+		return FilesInfo{}, []table.Row{}
+	}
+	if _, ok := fsys.(vfs.Local); !ok {
+		return getVFSRows(fsys, dir, fdir, opts)
+	}
+
 	files, _ := os.ReadDir(dir)
 	dirs := []table.Row{}
 	regularFiles := []table.Row{}
 
-	row, err := getTableRowForPath(dir, "..")
+	row, err := getTableRowForPath(dir, "..", opts)
 	if err == nil {
 		dirs = append(dirs, row)
 	}
@@ -64,7 +132,7 @@ func GetTableRows(dir string) (FilesInfo, []table.Row) {
 		if file.Name() == "." || file.Name() == ".." {
 			continue
 		}
-		row, err := getTableRowForPath(dir, file.Name())
+		row, err := getTableRowForPath(dir, file.Name(), opts)
 		if err != nil {
 			continue
 		}
@@ -76,13 +144,58 @@ func GetTableRows(dir string) (FilesInfo, []table.Row) {
 		}
 	}
 
-	// Sort directories and files alphabetically
-	sort.Slice(dirs[1:], func(i, j int) bool {
-		return dirs[i+1].Data["name"].(string) < dirs[j+1].Data["name"].(string)
-	})
-	sort.Slice(regularFiles, func(i, j int) bool {
-		return regularFiles[i].Data["name"].(string) < regularFiles[j].Data["name"].(string)
-	})
+	return combineRows(dir, dirs, regularFiles, opts)
+}
+
+// getVFSRows lists a directory of a backend other than the local file
+// system, dir being the path of the panel and fdir the one of the backend.
+func getVFSRows(fsys vfs.FS, dir string, fdir string, opts Options) (FilesInfo, []table.Row) {
+	dirs := []table.Row{}
+	regularFiles := []table.Row{}
+
+	opts.remote = true
+	opts.DirSizes = nil
+	if sizer, ok := fsys.(vfs.DirSizer); ok {
+		opts.DirSizes = func(p string, _ time.Time) (uint64, bool) {
+			return sizer.DirSize(vfs.JoinIn(fsys, fdir, vfs.Base(p)))
+		}
+	}
+
+	parent := vfs.Dir(dir)
+	if pfs, pdir, err := vfs.ResolveDir(parent); err == nil {
+		if info, err := pfs.Stat(pdir); err == nil {
+			dirs = append(dirs, newTableRow(parent, "..", info, info.Mode(), opts))
+		}
+	}
+
+	infos, err := fsys.ReadDir(fdir)
+	if err != nil {
+		return FilesInfo{}, dirs
+	}
+	for _, info := range infos {
+		// Follow the symlinks, keeping the mode of the link itself
+		mode := info.Mode()
+		if mode&fs.ModeSymlink != 0 {
+			if target, err := fsys.Stat(vfs.JoinIn(fsys, fdir, info.Name())); err == nil {
+				info = target
+			}
+		}
+		row := newTableRow(vfs.Join(dir, info.Name()), info.Name(), info, mode, opts)
+		if info.IsDir() {
+			dirs = append(dirs, row)
+		} else {
+			regularFiles = append(regularFiles, row)
+		}
+	}
+
+	return combineRows(dir, dirs, regularFiles, opts)
+}
+
+func combineRows(dir string, dirs []table.Row, regularFiles []table.Row, opts Options) (FilesInfo, []table.Row) {
+	if opts.Filter != "" {
+		dirs = filterRows(dirs, opts.Filter)
+		regularFiles = filterRows(regularFiles, opts.Filter)
+	}
 
 	totalDirs := len(dirs) - 1 // Exclude ".."
 	totalFiles := len(regularFiles)
@@ -94,7 +207,25 @@ func GetTableRows(dir string) (FilesInfo, []table.Row) {
 	}
 
 	// Combine directories and files
-	return info, append(dirs, regularFiles...)
+	rows := append(dirs, regularFiles...)
+	if opts.shows("git") && !opts.remote {
+		status := gitStatus(dir)
+		for _, row := range rows {
+			name, _ := row.Data["name"].(string)
+			row.Data["git"] = status[name]
+		}
+	}
+
+	if opts.Icons != nil {
+		for _, row := range rows {
+			name, _ := row.Data["name"].(string)
+			mode, _ := row.Data["umode"].(fs.FileMode)
+			row.Data["icon"] = opts.Icons.Icon(name, mode)
+		}
+	}
+	SortRows(rows, opts.Sort)
+
+	return info, rows
 }
 
 func CountTableRows(rows []table.Row) (int, int, int) {
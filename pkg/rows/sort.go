@@ -0,0 +1,78 @@
+package rows
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/evertras/bubble-table/table"
+)
+
+var SortKeys = []string{"name", "size", "modified", "ext"}
+
+type SortOptions struct {
+	By        string `toml:"by"`
+	Reverse   bool   `toml:"reverse"`
+	DirsFirst bool   `toml:"dirs_first"`
+}
+
+func DefaultSortOptions() SortOptions {
+	return SortOptions{By: "name", DirsFirst: true}
+}
+
+func ValidSortKey(key string) bool {
+	for _, k := range SortKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// SortRows sorts the rows in place, keeping the ".." row on top.
+func SortRows(rows []table.Row, opts SortOptions) {
+	if len(rows) > 0 && rows[0].Data["name"] == ".." {
+		rows = rows[1:]
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i].Data, rows[j].Data
+
+		if opts.DirsFirst && a["dir"] != b["dir"] {
+			return a["dir"] == true
+		}
+
+		c := compareRows(a, b, opts.By)
+		if c == 0 {
+			c = strings.Compare(a["name"].(string), b["name"].(string))
+		}
+		if opts.Reverse {
+			return c > 0
+		}
+		return c < 0
+	})
+}
+
+func compareRows(a table.RowData, b table.RowData, by string) int {
+	switch by {
+	case "size":
+		as, _ := a["usize"].(uint64)
+		bs, _ := b["usize"].(uint64)
+		if as == bs {
+			return 0
+		}
+		if as < bs {
+			return -1
+		}
+		return 1
+	case "modified":
+		at, _ := a["umodified"].(time.Time)
+		bt, _ := b["umodified"].(time.Time)
+		return at.Compare(bt)
+	case "ext":
+		ae, _ := a["ext"].(string)
+		be, _ := b["ext"].(string)
+		return strings.Compare(strings.ToLower(ae), strings.ToLower(be))
+	}
+	return strings.Compare(a["name"].(string), b["name"].(string))
+}
@@ -0,0 +1,66 @@
+package rows
+
+import (
+	"os/user"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// fileStat holds the metadata not available from os.FileInfo.
+type fileStat struct {
+	uid      string
+	gid      string
+	inode    uint64
+	nlink    uint64
+	created  time.Time
+	accessed time.Time
+}
+
+var (
+	namesMu    sync.Mutex
+	userNames  = map[string]string{}
+	groupNames = map[string]string{}
+)
+
+func userName(uid string) string {
+	if uid == "" {
+		return ""
+	}
+
+	namesMu.Lock()
+	defer namesMu.Unlock()
+
+	if name, ok := userNames[uid]; ok {
+		return name
+	}
+	name := uid
+	if u, err := user.LookupId(uid); err == nil {
+		name = u.Username
+	}
+	userNames[uid] = name
+	return name
+}
+
+func groupName(gid string) string {
+	if gid == "" {
+		return ""
+	}
+
+	namesMu.Lock()
+	defer namesMu.Unlock()
+
+	if name, ok := groupNames[gid]; ok {
+		return name
+	}
+	name := gid
+	if g, err := user.LookupGroupId(gid); err == nil {
+		name = g.Name
+	}
+	groupNames[gid] = name
+	return name
+}
+
+func formatUint(n uint64) string {
+	return strconv.FormatUint(n, 10)
+}
@@ -0,0 +1,13 @@
+//go:build darwin || freebsd || netbsd
+
+package rows
+
+import (
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func birthTime(_ string, st unix.Stat_t) time.Time {
+	return time.Unix(st.Btim.Unix())
+}
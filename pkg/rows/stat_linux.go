@@ -0,0 +1,19 @@
+//go:build linux
+
+package rows
+
+import (
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// birthTime uses statx, as the creation time is not part of stat on Linux.
+func birthTime(path string, _ unix.Stat_t) time.Time {
+	var stx unix.Statx_t
+	err := unix.Statx(unix.AT_FDCWD, path, unix.AT_SYMLINK_NOFOLLOW, unix.STATX_BTIME, &stx)
+	if err != nil || stx.Mask&unix.STATX_BTIME == 0 || stx.Btime.Sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(stx.Btime.Sec, int64(stx.Btime.Nsec))
+}
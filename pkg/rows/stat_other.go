@@ -0,0 +1,14 @@
+//go:build !windows && !linux && !darwin && !freebsd && !netbsd
+
+package rows
+
+import (
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// The creation time is not available on this platform
+func birthTime(_ string, _ unix.Stat_t) time.Time {
+	return time.Time{}
+}
@@ -0,0 +1,27 @@
+//go:build !windows
+
+package rows
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func statFile(path string, info os.FileInfo) fileStat {
+	var st unix.Stat_t
+	if err := unix.Lstat(path, &st); err != nil {
+		return fileStat{}
+	}
+
+	return fileStat{
+		uid:      strconv.FormatUint(uint64(st.Uid), 10),
+		gid:      strconv.FormatUint(uint64(st.Gid), 10),
+		inode:    uint64(st.Ino),
+		nlink:    uint64(st.Nlink),
+		created:  birthTime(path, st),
+		accessed: time.Unix(st.Atim.Unix()),
+	}
+}
@@ -0,0 +1,22 @@
+//go:build windows
+
+package rows
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+func statFile(_ string, info os.FileInfo) fileStat {
+	data, ok := info.Sys().(*syscall.Win32FileAttributeData)
+	if !ok {
+		return fileStat{}
+	}
+
+	return fileStat{
+		nlink:    1,
+		created:  time.Unix(0, data.CreationTime.Nanoseconds()),
+		accessed: time.Unix(0, data.LastAccessTime.Nanoseconds()),
+	}
+}
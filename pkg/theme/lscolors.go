@@ -0,0 +1,266 @@
+package theme
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// LSColors colours file names like ls does, from the LS_COLORS variable or
+// a dircolors database.
+type LSColors struct {
+	types    map[string]lipgloss.Style
+	suffixes []suffixStyle
+}
+
+type suffixStyle struct {
+	suffix string
+	style  lipgloss.Style
+}
+
+// Keywords of the dircolors database and their LS_COLORS codes
+var dircolorsKeywords = map[string]string{
+	"NORMAL":                "no",
+	"NORM":                  "no",
+	"FILE":                  "fi",
+	"RESET":                 "rs",
+	"DIR":                   "di",
+	"LNK":                   "ln",
+	"LINK":                  "ln",
+	"SYMLINK":               "ln",
+	"ORPHAN":                "or",
+	"MISSING":               "mi",
+	"FIFO":                  "pi",
+	"PIPE":                  "pi",
+	"SOCK":                  "so",
+	"BLK":                   "bd",
+	"BLOCK":                 "bd",
+	"CHR":                   "cd",
+	"CHAR":                  "cd",
+	"DOOR":                  "do",
+	"EXEC":                  "ex",
+	"SETUID":                "su",
+	"SETGID":                "sg",
+	"STICKY":                "st",
+	"OTHER_WRITABLE":        "ow",
+	"OWR":                   "ow",
+	"STICKY_OTHER_WRITABLE": "tw",
+	"OWT":                   "tw",
+	"MULTIHARDLINK":         "mh",
+	"CAPABILITY":            "ca",
+}
+
+// LoadLSColors reads the dircolors database at path, or the LS_COLORS
+// variable if path is empty. It returns nil when there is nothing to load.
+func LoadLSColors(path string) (*LSColors, error) {
+	if path == "" {
+		value := os.Getenv("LS_COLORS")
+		if value == "" {
+			return nil, nil
+		}
+		return ParseLSColors(value), nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading dircolors database: %v", err)
+	}
+	defer f.Close()
+
+	return ParseDircolors(f)
+}
+
+// ParseLSColors parses the LS_COLORS format, e.g. "di=01;34:*.tar=01;31".
+func ParseLSColors(value string) *LSColors {
+	c := &LSColors{types: map[string]lipgloss.Style{}}
+	for _, entry := range strings.Split(value, ":") {
+		key, sgr, ok := strings.Cut(entry, "=")
+		if !ok || key == "" {
+			continue
+		}
+		c.add(key, sgr)
+	}
+	return c
+}
+
+// ParseDircolors parses a database in the format written by
+// `dircolors --print-database`. TERM and COLORTERM filters are ignored.
+func ParseDircolors(r io.Reader) (*LSColors, error) {
+	c := &LSColors{types: map[string]lipgloss.Style{}}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		key, sgr := fields[0], fields[1]
+		switch {
+		case strings.HasPrefix(key, "."):
+			c.add("*"+key, sgr)
+		case strings.HasPrefix(key, "*"):
+			c.add(key, sgr)
+		default:
+			if code, ok := dircolorsKeywords[strings.ToUpper(key)]; ok {
+				c.add(code, sgr)
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading dircolors database: %v", err)
+	}
+	return c, nil
+}
+
+func (c *LSColors) add(key string, sgr string) {
+	if strings.HasPrefix(key, "*") {
+		c.suffixes = append(c.suffixes, suffixStyle{suffix: strings.ToLower(key[1:]), style: sgrStyle(sgr)})
+		return
+	}
+	c.types[key] = sgrStyle(sgr)
+}
+
+// Style returns the style for a file following the precedence of ls: the
+// special types and permissions first, then the suffixes for regular files.
+func (c *LSColors) Style(name string, mode fs.FileMode) (lipgloss.Style, bool) {
+	if c == nil {
+		return lipgloss.Style{}, false
+	}
+
+	key := "fi"
+	switch {
+	case mode&fs.ModeSymlink != 0:
+		key = "ln"
+	case mode.IsDir():
+		key = "di"
+		sticky := mode&fs.ModeSticky != 0
+		otherWritable := mode&0o002 != 0
+		if sticky && otherWritable {
+			key = c.first("tw", "ow", "st", "di")
+		} else if otherWritable {
+			key = c.first("ow", "di")
+		} else if sticky {
+			key = c.first("st", "di")
+		}
+	case mode&fs.ModeNamedPipe != 0:
+		key = "pi"
+	case mode&fs.ModeSocket != 0:
+		key = "so"
+	case mode&fs.ModeCharDevice != 0:
+		key = "cd"
+	case mode&fs.ModeDevice != 0:
+		key = "bd"
+	case mode&fs.ModeSetuid != 0 && c.has("su"):
+		key = "su"
+	case mode&fs.ModeSetgid != 0 && c.has("sg"):
+		key = "sg"
+	case mode&0o111 != 0 && c.has("ex"):
+		key = "ex"
+	}
+
+	if key == "fi" {
+		lower := strings.ToLower(name)
+		for _, s := range c.suffixes {
+			if strings.HasSuffix(lower, s.suffix) {
+				return s.style, true
+			}
+		}
+	}
+
+	style, ok := c.types[key]
+	return style, ok
+}
+
+func (c *LSColors) has(key string) bool {
+	_, ok := c.types[key]
+	return ok
+}
+
+func (c *LSColors) first(keys ...string) string {
+	for _, key := range keys {
+		if c.has(key) {
+			return key
+		}
+	}
+	return keys[len(keys)-1]
+}
+
+// sgrStyle converts a Select Graphic Rendition sequence like "01;38;5;208"
+// to a style.
+func sgrStyle(sgr string) lipgloss.Style {
+	style := lipgloss.NewStyle()
+
+	codes := []int{}
+	for _, part := range strings.Split(sgr, ";") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			continue
+		}
+		codes = append(codes, n)
+	}
+
+	for i := 0; i < len(codes); i++ {
+		code := codes[i]
+		switch {
+		case code == 1:
+			style = style.Bold(true)
+		case code == 2:
+			style = style.Faint(true)
+		case code == 3:
+			style = style.Italic(true)
+		case code == 4:
+			style = style.Underline(true)
+		case code == 5:
+			style = style.Blink(true)
+		case code == 7:
+			style = style.Reverse(true)
+		case code == 9:
+			style = style.Strikethrough(true)
+		case code >= 30 && code <= 37:
+			style = style.Foreground(lipgloss.Color(strconv.Itoa(code - 30)))
+		case code >= 90 && code <= 97:
+			style = style.Foreground(lipgloss.Color(strconv.Itoa(code - 90 + 8)))
+		case code >= 40 && code <= 47:
+			style = style.Background(lipgloss.Color(strconv.Itoa(code - 40)))
+		case code >= 100 && code <= 107:
+			style = style.Background(lipgloss.Color(strconv.Itoa(code - 100 + 8)))
+		case code == 38 || code == 48:
+			color, n := extendedColor(codes[i+1:])
+			i += n
+			if color == "" {
+				continue
+			}
+			if code == 38 {
+				style = style.Foreground(lipgloss.Color(color))
+			} else {
+				style = style.Background(lipgloss.Color(color))
+			}
+		}
+	}
+
+	return style
+}
+
+// extendedColor parses the arguments of the 38 and 48 codes, returning the
+// colour and the number of codes consumed.
+func extendedColor(codes []int) (string, int) {
+	if len(codes) >= 2 && codes[0] == 5 {
+		return strconv.Itoa(codes[1]), 2
+	}
+	if len(codes) >= 4 && codes[0] == 2 {
+		return fmt.Sprintf("#%02x%02x%02x", codes[1]&0xff, codes[2]&0xff, codes[3]&0xff), 4
+	}
+	return "", len(codes)
+}
@@ -0,0 +1,226 @@
+package theme
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Theme selects a preset and optionally overrides some of its colours.
+type Theme struct {
+	Preset string `toml:"preset"`
+	// LSColors colours the files by type and extension using LS_COLORS or
+	// the dircolors database in Dircolors.
+	LSColors  bool   `toml:"ls_colors"`
+	Dircolors string `toml:"dircolors"`
+	Colors
+}
+
+// Colors holds the colours of the interface, as hex strings (#RGB or
+// #RRGGBB) or ANSI 256 colour numbers. Empty values use the terminal
+// default colours.
+type Colors struct {
+	Header         string `toml:"header"`
+	Directory      string `toml:"directory"`
+	File           string `toml:"file"`
+	Parent         string `toml:"parent"`
+	HighlightFg    string `toml:"highlight_fg"`
+	HighlightBg    string `toml:"highlight_bg"`
+	BorderActive   string `toml:"border_active"`
+	BorderInactive string `toml:"border_inactive"`
+	FooterLabel    string `toml:"footer_label"`
+	FooterValue    string `toml:"footer_value"`
+	DialogBorder   string `toml:"dialog_border"`
+	ButtonFg       string `toml:"button_fg"`
+	ButtonBg       string `toml:"button_bg"`
+	ButtonActiveBg string `toml:"button_active_bg"`
+	InputFg        string `toml:"input_fg"`
+	InputBg        string `toml:"input_bg"`
+	// Trees of the structured previews and the differences of the files
+	Key      string `toml:"key"`
+	String   string `toml:"string"`
+	Number   string `toml:"number"`
+	Literal  string `toml:"literal"`
+	Error    string `toml:"error"`
+	Deleted  string `toml:"deleted"`
+	Inserted string `toml:"inserted"`
+}
+
+const (
+	ColWhite       = "#FFFFFF"
+	ColBlack       = "#000000"
+	ColDarkGray    = "#333333"
+	ColViolet      = "#874BFD"
+	ColLightBlue   = "#87afff"
+	ColPink        = "#F25D94"
+	ColLightYellow = "#FFF7DB"
+	ColDarkYellow  = "#888B7E"
+	ColYellow      = "#ffd703"
+	ColOrange      = "#ffaf00"
+	ColGreen       = "#a8cc8c"
+	ColRed         = "#ff5f5f"
+	ColLightGreen  = "#5fd75f"
+)
+
+const (
+	PresetDark         = "dark"
+	PresetLight        = "light"
+	PresetHighContrast = "high-contrast"
+	PresetMono         = "mono"
+)
+
+var Presets = []string{PresetDark, PresetLight, PresetHighContrast, PresetMono}
+
+var presetColors = map[string]Colors{
+	PresetDark: {
+		Header:         ColPink,
+		Directory:      ColOrange,
+		File:           ColLightBlue,
+		Parent:         ColDarkYellow,
+		HighlightFg:    ColWhite,
+		HighlightBg:    ColViolet,
+		BorderActive:   ColWhite,
+		BorderInactive: ColDarkGray,
+		FooterLabel:    ColViolet,
+		FooterValue:    ColOrange,
+		DialogBorder:   ColViolet,
+		ButtonFg:       ColLightYellow,
+		ButtonBg:       ColDarkYellow,
+		ButtonActiveBg: ColPink,
+		InputFg:        ColLightYellow,
+		InputBg:        ColDarkGray,
+		Key:            ColLightBlue,
+		String:         ColGreen,
+		Number:         ColOrange,
+		Literal:        ColPink,
+		Error:          ColRed,
+		Deleted:        ColRed,
+		Inserted:       ColLightGreen,
+	},
+	PresetLight: {
+		Header:         "#D7005F",
+		Directory:      "#AF5F00",
+		File:           "#005FAF",
+		Parent:         "#767676",
+		HighlightFg:    ColWhite,
+		HighlightBg:    "#5F5FD7",
+		BorderActive:   ColBlack,
+		BorderInactive: "#BCBCBC",
+		FooterLabel:    "#5F5FD7",
+		FooterValue:    "#AF5F00",
+		DialogBorder:   "#5F5FD7",
+		ButtonFg:       ColWhite,
+		ButtonBg:       "#8A8A8A",
+		ButtonActiveBg: "#D7005F",
+		InputFg:        ColBlack,
+		InputBg:        "#E4E4E4",
+		Key:            "#005FAF",
+		String:         "#5F8700",
+		Number:         "#AF5F00",
+		Literal:        "#D7005F",
+		Error:          "#D70000",
+		Deleted:        "#D70000",
+		Inserted:       "#008700",
+	},
+	PresetHighContrast: {
+		Header:         ColYellow,
+		Directory:      "#00FFFF",
+		File:           ColWhite,
+		Parent:         ColYellow,
+		HighlightFg:    ColBlack,
+		HighlightBg:    ColYellow,
+		BorderActive:   ColYellow,
+		BorderInactive: ColWhite,
+		FooterLabel:    "#00FFFF",
+		FooterValue:    ColWhite,
+		DialogBorder:   ColYellow,
+		ButtonFg:       ColWhite,
+		ButtonBg:       ColBlack,
+		ButtonActiveBg: "#0000FF",
+		InputFg:        ColBlack,
+		InputBg:        ColWhite,
+		Key:            "#00FFFF",
+		String:         "#00FF00",
+		Number:         ColYellow,
+		Literal:        "#FF00FF",
+		Error:          "#FF0000",
+		Deleted:        "#FF0000",
+		Inserted:       "#00FF00",
+	},
+	// Monochrome relies only on attributes such as bold and reverse
+	PresetMono: {},
+}
+
+// DefaultPreset is the monochrome theme when NO_COLOR is set
+// (https://no-color.org), the dark one otherwise.
+func DefaultPreset() string {
+	if os.Getenv("NO_COLOR") != "" {
+		return PresetMono
+	}
+	return PresetDark
+}
+
+func Default() Theme {
+	return Theme{
+		Preset:   DefaultPreset(),
+		LSColors: true,
+	}
+}
+
+func ValidPreset(name string) bool {
+	_, ok := presetColors[name]
+	return ok
+}
+
+// Resolve returns the colours of the preset with the overrides applied.
+func (t Theme) Resolve() Colors {
+	colors := presetColors[t.Preset]
+	if t.Preset == PresetMono {
+		return colors
+	}
+
+	dst := reflect.ValueOf(&colors).Elem()
+	src := reflect.ValueOf(t.Colors)
+	for i := 0; i < src.NumField(); i++ {
+		if c := src.Field(i).String(); c != "" {
+			dst.Field(i).SetString(c)
+		}
+	}
+	return colors
+}
+
+func (t Theme) Mono() bool {
+	return t.Preset == PresetMono
+}
+
+var hexColorRegexp = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+func ValidColor(c string) bool {
+	if hexColorRegexp.MatchString(c) {
+		return true
+	}
+	n, err := strconv.Atoi(c)
+	return err == nil && n >= 0 && n <= 255
+}
+
+// Validate returns an error for the unknown preset and for each colour that
+// is not valid.
+func (t Theme) Validate() []error {
+	errs := []error{}
+	if !ValidPreset(t.Preset) {
+		errs = append(errs, fmt.Errorf("theme.preset: unknown preset %q, expected one of %s", t.Preset, strings.Join(Presets, ", ")))
+	}
+
+	v := reflect.ValueOf(t.Colors)
+	for i := 0; i < v.NumField(); i++ {
+		c := v.Field(i).String()
+		if c != "" && !ValidColor(c) {
+			name := v.Type().Field(i).Tag.Get("toml")
+			errs = append(errs, fmt.Errorf("theme.%s: invalid colour %q, expected #RRGGBB, #RGB or an ANSI colour number (0-255)", name, c))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,64 @@
+package vfs
+
+import (
+	"io"
+	"io/fs"
+
+	"github.com/sandrolain/gommander/pkg/archive"
+)
+
+// archiveFS shows an archive as a read-only directory, the paths are the
+// slash-separated names of the entries, "" being the root.
+type archiveFS struct {
+	a *archive.Archive
+}
+
+func (f *archiveFS) Stat(path string) (fs.FileInfo, error) {
+	return f.a.Stat(path)
+}
+
+func (f *archiveFS) Lstat(path string) (fs.FileInfo, error) {
+	return f.a.Stat(path)
+}
+
+func (f *archiveFS) ReadDir(path string) ([]fs.FileInfo, error) {
+	return f.a.List(path)
+}
+
+func (f *archiveFS) Open(path string) (io.ReadCloser, error) {
+	return f.a.Open(path)
+}
+
+func (f *archiveFS) Create(path string, perm fs.FileMode) (io.WriteCloser, error) {
+	return nil, ErrReadOnly
+}
+
+func (f *archiveFS) Rename(from string, to string) error {
+	return ErrReadOnly
+}
+
+func (f *archiveFS) Remove(path string) error {
+	return ErrReadOnly
+}
+
+func (f *archiveFS) Mkdir(path string, perm fs.FileMode) error {
+	return ErrReadOnly
+}
+
+func (f *archiveFS) ReadOnly() bool {
+	return true
+}
+
+// DirSize returns the total size of the files of the directory, known from
+// the index of the archive.
+func (f *archiveFS) DirSize(path string) (uint64, bool) {
+	info, err := f.a.Stat(path)
+	if err != nil {
+		return 0, false
+	}
+	return uint64(info.Size()), true
+}
+
+func (f *archiveFS) Extract(path string, dstDir string, overwrite bool) error {
+	return f.a.Extract(path, dstDir, overwrite)
+}
@@ -0,0 +1,139 @@
+package vfs
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeZip creates an archive with a file and a directory in dir.
+func writeZip(t *testing.T, dir string) string {
+	t.Helper()
+	p := filepath.Join(dir, "a.zip")
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for name, content := range map[string]string{"file.txt": "file", "sub/inner.txt": "inner"} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestResolveArchive(t *testing.T) {
+	p := writeZip(t, t.TempDir())
+
+	fsys, _, err := Resolve(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fsys.(Local); !ok {
+		t.Errorf("Resolve(%v) = %T, want Local", p, fsys)
+	}
+	if !IsLocal(p) || ReadOnly(p) || !IsArchive(p) {
+		t.Errorf("archive %v: IsLocal %v, ReadOnly %v, IsArchive %v", p, IsLocal(p), ReadOnly(p), IsArchive(p))
+	}
+
+	fsys, inner, err := ResolveDir(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fsys.(*archiveFS); !ok || inner != "" {
+		t.Errorf("ResolveDir(%v) = %T %q, want the archive root", p, fsys, inner)
+	}
+
+	entry := filepath.Join(p, "sub")
+	fsys, inner, err = Resolve(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fsys.(*archiveFS); !ok || inner != "sub" {
+		t.Errorf("Resolve(%v) = %T %q, want the archive entry", entry, fsys, inner)
+	}
+	if IsLocal(entry) || !ReadOnly(entry) || IsArchive(entry) {
+		t.Errorf("entry %v: IsLocal %v, ReadOnly %v, IsArchive %v", entry, IsLocal(entry), ReadOnly(entry), IsArchive(entry))
+	}
+	if root := Root(entry); root != "" {
+		t.Errorf("Root(%v) = %q, want none", entry, root)
+	}
+}
+
+func TestCopyArchive(t *testing.T) {
+	p := writeZip(t, t.TempDir())
+	dst := t.TempDir()
+
+	if err := Copy(p, dst, false); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filepath.Join(dst, "a.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.Mode().IsRegular() {
+		t.Errorf("copy of %v is not a file: %v", p, info.Mode())
+	}
+}
+
+func TestCopyFromArchive(t *testing.T) {
+	p := writeZip(t, t.TempDir())
+	dst := t.TempDir()
+
+	if err := Copy(filepath.Join(p, "sub"), dst, false); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dst, "sub", "inner.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "inner" {
+		t.Errorf("extracted %q, want %q", data, "inner")
+	}
+}
+
+func TestMoveArchive(t *testing.T) {
+	p := writeZip(t, t.TempDir())
+	dst := t.TempDir()
+
+	if err := Move(p, dst, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(p); !os.IsNotExist(err) {
+		t.Errorf("%v still exists after the move: %v", p, err)
+	}
+	if info, err := os.Stat(filepath.Join(dst, "a.zip")); err != nil || !info.Mode().IsRegular() {
+		t.Errorf("moved archive: %v", err)
+	}
+}
+
+func TestDeleteArchive(t *testing.T) {
+	p := writeZip(t, t.TempDir())
+
+	if err := Delete([]string{p}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(p); !os.IsNotExist(err) {
+		t.Errorf("%v still exists after the deletion: %v", p, err)
+	}
+}
+
+func TestDeleteInArchive(t *testing.T) {
+	p := writeZip(t, t.TempDir())
+
+	if err := Delete([]string{filepath.Join(p, "file.txt")}); err != ErrReadOnly {
+		t.Errorf("Delete in the archive = %v, want %v", err, ErrReadOnly)
+	}
+}
@@ -0,0 +1,156 @@
+package vfs
+
+import (
+	"fmt"
+	"io"
+
+	gfs "github.com/sandrolain/gommander/pkg/fs"
+)
+
+// Copy copies the file or the directory src to the directory dstDir, the
+// paths being the ones of the panels on any backend.
+func Copy(src string, dstDir string, overwrite bool) error {
+	sfs, sp, err := Resolve(src)
+	if err != nil {
+		return err
+	}
+	dfs, dp, err := Resolve(dstDir)
+	if err != nil {
+		return err
+	}
+
+	_, srcLocal := sfs.(Local)
+	_, dstLocal := dfs.(Local)
+	if srcLocal && dstLocal {
+		return gfs.CopyFile(sp, dp, overwrite)
+	}
+	if x, ok := sfs.(Extractor); ok && dstLocal {
+		return x.Extract(sp, dp, overwrite)
+	}
+	return copyTree(sfs, sp, dfs, JoinIn(dfs, dp, Base(src)), overwrite)
+}
+
+func copyTree(sfs FS, src string, dfs FS, dst string, overwrite bool) error {
+	info, err := sfs.Stat(src)
+	if err != nil {
+		return fmt.Errorf("error stating file %v: %v", src, err)
+	}
+
+	if info.IsDir() {
+		if _, err := dfs.Stat(dst); err != nil {
+			if err := dfs.Mkdir(dst, info.Mode().Perm()); err != nil {
+				return fmt.Errorf("error creating directory %v: %v", dst, err)
+			}
+		} else if !overwrite {
+			return fmt.Errorf("directory %v already exists", dst)
+		}
+
+		entries, err := sfs.ReadDir(src)
+		if err != nil {
+			return fmt.Errorf("error reading directory %v: %v", src, err)
+		}
+		for _, e := range entries {
+			if err := copyTree(sfs, JoinIn(sfs, src, e.Name()), dfs, JoinIn(dfs, dst, e.Name()), overwrite); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if !overwrite {
+		if _, err := dfs.Stat(dst); err == nil {
+			return fmt.Errorf("file %v already exists", dst)
+		}
+	}
+
+	r, err := sfs.Open(src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	w, err := dfs.Create(dst, info.Mode().Perm())
+	if err != nil {
+		return fmt.Errorf("error creating file %v: %v", dst, err)
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		w.Close()
+		return fmt.Errorf("error copying file %v to %v: %v", src, dst, err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("error copying file %v to %v: %v", src, dst, err)
+	}
+	return nil
+}
+
+// Move moves the file or the directory src to the directory dstDir, renaming
+// it on the same backend and copying it then removing it across backends.
+func Move(src string, dstDir string, overwrite bool) error {
+	sfs, sp, err := Resolve(src)
+	if err != nil {
+		return err
+	}
+	dfs, dp, err := Resolve(dstDir)
+	if err != nil {
+		return err
+	}
+
+	_, srcLocal := sfs.(Local)
+	_, dstLocal := dfs.(Local)
+	if srcLocal && dstLocal {
+		return gfs.MoveFile(sp, dp, overwrite)
+	}
+
+	if sfs == dfs {
+		dst := JoinIn(dfs, dp, Base(src))
+		if !overwrite {
+			if _, err := dfs.Stat(dst); err == nil {
+				return fmt.Errorf("file %v already exists", dst)
+			}
+		}
+		return sfs.Rename(sp, dst)
+	}
+
+	if err := Copy(src, dstDir, overwrite); err != nil {
+		return err
+	}
+	return RemoveAll(sfs, sp)
+}
+
+// RemoveAll removes the file or the directory with its content.
+func RemoveAll(fsys FS, p string) error {
+	if _, ok := fsys.(Local); ok {
+		return gfs.DeleteTrees([]string{p})
+	}
+
+	info, err := fsys.Lstat(p)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		entries, err := fsys.ReadDir(p)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if err := RemoveAll(fsys, JoinIn(fsys, p, e.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return fsys.Remove(p)
+}
+
+// Delete removes the files and the empty directories of the panels.
+func Delete(paths []string) error {
+	for _, p := range paths {
+		fsys, fp, err := Resolve(p)
+		if err != nil {
+			return err
+		}
+		if err := fsys.Remove(fp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package vfs
+
+import (
+	"io"
+	"sort"
+	"testing"
+)
+
+func writeFile(t *testing.T, fsys FS, p string, content string) {
+	t.Helper()
+	w, err := fsys.Create(p, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(w, content); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, fsys FS, p string) string {
+	t.Helper()
+	r, err := fsys.Open(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func names(t *testing.T, fsys FS, p string) []string {
+	t.Helper()
+	infos, err := fsys.ReadDir(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, info := range infos {
+		names = append(names, info.Name())
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,53 @@
+package vfs
+
+import (
+	"io"
+	"io/fs"
+	"os"
+)
+
+// Local is the file system of the machine.
+type Local struct{}
+
+func (Local) Stat(path string) (fs.FileInfo, error) {
+	return os.Stat(path)
+}
+
+func (Local) Lstat(path string) (fs.FileInfo, error) {
+	return os.Lstat(path)
+}
+
+func (Local) ReadDir(path string) ([]fs.FileInfo, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	infos := []fs.FileInfo{}
+	for _, e := range entries {
+		// The entries removed in the meantime are skipped
+		if info, err := e.Info(); err == nil {
+			infos = append(infos, info)
+		}
+	}
+	return infos, nil
+}
+
+func (Local) Open(path string) (io.ReadCloser, error) {
+	return os.Open(path)
+}
+
+func (Local) Create(path string, perm fs.FileMode) (io.WriteCloser, error) {
+	return os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+}
+
+func (Local) Rename(from string, to string) error {
+	return os.Rename(from, to)
+}
+
+func (Local) Remove(path string) error {
+	return os.Remove(path)
+}
+
+func (Local) Mkdir(path string, perm fs.FileMode) error {
+	return os.Mkdir(path, perm)
+}
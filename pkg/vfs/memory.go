@@ -0,0 +1,208 @@
+package vfs
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memNode is a file or a directory of the memory file system.
+type memNode struct {
+	name    string
+	mode    fs.FileMode
+	modTime time.Time
+	data    []byte
+}
+
+func (n *memNode) Name() string       { return n.name }
+func (n *memNode) Size() int64        { return int64(len(n.data)) }
+func (n *memNode) Mode() fs.FileMode  { return n.mode }
+func (n *memNode) ModTime() time.Time { return n.modTime }
+func (n *memNode) IsDir() bool        { return n.mode.IsDir() }
+func (n *memNode) Sys() any           { return nil }
+
+// Memory is a file system kept in memory, used by the tests and as a
+// scratch space. The paths are slash-separated and absolute.
+type Memory struct {
+	mu    sync.Mutex
+	nodes map[string]*memNode
+}
+
+func NewMemory() *Memory {
+	return &Memory{nodes: map[string]*memNode{
+		"/": {name: "/", mode: fs.ModeDir | 0o755, modTime: time.Now()},
+	}}
+}
+
+func memPath(p string) string {
+	return path.Clean("/" + p)
+}
+
+func memError(op string, p string, err error) error {
+	return &fs.PathError{Op: op, Path: p, Err: err}
+}
+
+// stat returns a copy of the node, safe to use without the lock.
+func (m *Memory) stat(op string, p string) (*memNode, error) {
+	n, ok := m.nodes[memPath(p)]
+	if !ok {
+		return nil, memError(op, p, fs.ErrNotExist)
+	}
+	c := *n
+	return &c, nil
+}
+
+func (m *Memory) Stat(p string) (fs.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stat("stat", p)
+}
+
+func (m *Memory) Lstat(p string) (fs.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stat("lstat", p)
+}
+
+func (m *Memory) ReadDir(p string) ([]fs.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	dir, err := m.stat("readdir", p)
+	if err != nil {
+		return nil, err
+	}
+	if !dir.IsDir() {
+		return nil, memError("readdir", p, fs.ErrInvalid)
+	}
+
+	p = memPath(p)
+	infos := []fs.FileInfo{}
+	for name, n := range m.nodes {
+		if name != "/" && path.Dir(name) == p {
+			c := *n
+			infos = append(infos, &c)
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name() < infos[j].Name() })
+	return infos, nil
+}
+
+func (m *Memory) Open(p string) (io.ReadCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n, err := m.stat("open", p)
+	if err != nil {
+		return nil, err
+	}
+	if n.IsDir() {
+		return nil, memError("open", p, fs.ErrInvalid)
+	}
+	return io.NopCloser(bytes.NewReader(n.data)), nil
+}
+
+// memFile stores its content in the file system when closed.
+type memFile struct {
+	m    *Memory
+	path string
+	perm fs.FileMode
+	buf  bytes.Buffer
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	return f.buf.Write(p)
+}
+
+func (f *memFile) Close() error {
+	f.m.mu.Lock()
+	defer f.m.mu.Unlock()
+	f.m.nodes[f.path] = &memNode{name: path.Base(f.path), mode: f.perm, modTime: time.Now(), data: f.buf.Bytes()}
+	return nil
+}
+
+func (m *Memory) Create(p string, perm fs.FileMode) (io.WriteCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p = memPath(p)
+	if parent, ok := m.nodes[path.Dir(p)]; !ok || !parent.IsDir() {
+		return nil, memError("create", p, fs.ErrNotExist)
+	}
+	if n, ok := m.nodes[p]; ok && n.IsDir() {
+		return nil, memError("create", p, fs.ErrExist)
+	}
+	return &memFile{m: m, path: p, perm: perm.Perm()}, nil
+}
+
+func (m *Memory) Rename(from string, to string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	from, to = memPath(from), memPath(to)
+	n, ok := m.nodes[from]
+	if !ok || from == "/" {
+		return memError("rename", from, fs.ErrNotExist)
+	}
+	if parent, ok := m.nodes[path.Dir(to)]; !ok || !parent.IsDir() {
+		return memError("rename", to, fs.ErrNotExist)
+	}
+	if n.IsDir() && strings.HasPrefix(to, from+"/") {
+		return memError("rename", to, fs.ErrInvalid)
+	}
+
+	if target, ok := m.nodes[to]; ok && (target.IsDir() || n.IsDir()) {
+		return memError("rename", to, fs.ErrExist)
+	}
+
+	// Move the node and, for the directories, all the descendants
+	moved := map[string]*memNode{}
+	for name, child := range m.nodes {
+		if name == from || strings.HasPrefix(name, from+"/") {
+			delete(m.nodes, name)
+			moved[to+strings.TrimPrefix(name, from)] = child
+		}
+	}
+	for name, child := range moved {
+		m.nodes[name] = child
+	}
+	n.name = path.Base(to)
+	return nil
+}
+
+func (m *Memory) Remove(p string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p = memPath(p)
+	if _, ok := m.nodes[p]; !ok || p == "/" {
+		return memError("remove", p, fs.ErrNotExist)
+	}
+	for name := range m.nodes {
+		if strings.HasPrefix(name, p+"/") {
+			return memError("remove", p, ErrNotEmpty)
+		}
+	}
+	delete(m.nodes, p)
+	return nil
+}
+
+func (m *Memory) Mkdir(p string, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p = memPath(p)
+	if _, ok := m.nodes[p]; ok {
+		return memError("mkdir", p, fs.ErrExist)
+	}
+	if parent, ok := m.nodes[path.Dir(p)]; !ok || !parent.IsDir() {
+		return memError("mkdir", p, fs.ErrNotExist)
+	}
+	m.nodes[p] = &memNode{name: path.Base(p), mode: fs.ModeDir | perm.Perm(), modTime: time.Now()}
+	return nil
+}
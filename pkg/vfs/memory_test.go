@@ -0,0 +1,143 @@
+package vfs
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+// newTestMemory mounts a memory file system holding /dir/a.txt and
+// /dir/sub/b.txt at mem://<name>.
+func newTestMemory(t *testing.T, name string) (*Memory, string) {
+	t.Helper()
+	m := NewMemory()
+	for _, dir := range []string{"/dir", "/dir/sub"} {
+		if err := m.Mkdir(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(t, m, "/dir/a.txt", "a")
+	writeFile(t, m, "/dir/sub/b.txt", "b")
+
+	root := "mem://" + strings.ToLower(strings.ReplaceAll(t.Name(), "/", "-")) + "-" + name
+	Mount(root, m)
+	return m, root
+}
+
+func TestMemoryRemoveNotEmpty(t *testing.T) {
+	m, _ := newTestMemory(t, "m")
+
+	if err := m.Remove("/dir/sub"); !errors.Is(err, ErrNotEmpty) {
+		t.Errorf("Remove of a non-empty directory = %v, want %v", err, ErrNotEmpty)
+	}
+	if err := m.Remove("/dir/sub/b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Remove("/dir/sub"); err != nil {
+		t.Errorf("Remove of an empty directory = %v", err)
+	}
+	if err := m.Remove("/missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Remove of a missing file = %v, want %v", err, fs.ErrNotExist)
+	}
+}
+
+func TestMemoryRenameDir(t *testing.T) {
+	m, _ := newTestMemory(t, "m")
+
+	if err := m.Rename("/dir", "/dir/sub/dir"); err == nil {
+		t.Errorf("renaming a directory into itself succeeded")
+	}
+	if err := m.Rename("/dir", "/moved"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Stat("/dir"); err == nil {
+		t.Errorf("/dir still exists after the rename")
+	}
+	info, err := m.Stat("/moved")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Name() != "moved" || !info.IsDir() {
+		t.Errorf("renamed directory: name %q, dir %v", info.Name(), info.IsDir())
+	}
+	if got := readFile(t, m, "/moved/sub/b.txt"); got != "b" {
+		t.Errorf("file of the renamed directory = %q, want %q", got, "b")
+	}
+	if got := names(t, m, "/moved"); strings.Join(got, ",") != "a.txt,sub" {
+		t.Errorf("ReadDir of the renamed directory = %v", got)
+	}
+
+	if err := m.Mkdir("/other", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Rename("/moved", "/other"); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("renaming over a directory = %v, want %v", err, fs.ErrExist)
+	}
+}
+
+func TestRemoveAll(t *testing.T) {
+	m, _ := newTestMemory(t, "m")
+
+	if err := RemoveAll(m, "/dir"); err != nil {
+		t.Fatal(err)
+	}
+	if got := names(t, m, "/"); len(got) != 0 {
+		t.Errorf("ReadDir after RemoveAll = %v", got)
+	}
+}
+
+func TestCopyTree(t *testing.T) {
+	src, _ := newTestMemory(t, "src")
+	dst := NewMemory()
+
+	if err := copyTree(src, "/dir", dst, "/copy", false); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, dst, "/copy/a.txt"); got != "a" {
+		t.Errorf("copied file = %q, want %q", got, "a")
+	}
+	if got := readFile(t, dst, "/copy/sub/b.txt"); got != "b" {
+		t.Errorf("copied file = %q, want %q", got, "b")
+	}
+
+	if err := copyTree(src, "/dir", dst, "/copy", false); err == nil {
+		t.Errorf("copying over an existing directory without overwrite succeeded")
+	}
+	writeFile(t, src, "/dir/a.txt", "changed")
+	if err := copyTree(src, "/dir", dst, "/copy", true); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, dst, "/copy/a.txt"); got != "changed" {
+		t.Errorf("overwritten file = %q, want %q", got, "changed")
+	}
+}
+
+func TestMoveAcrossBackends(t *testing.T) {
+	src, srcRoot := newTestMemory(t, "src")
+	dst, dstRoot := newTestMemory(t, "dst")
+
+	if err := Move(srcRoot+"/dir/sub", dstRoot+"/", false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := src.Stat("/dir/sub"); err == nil {
+		t.Errorf("/dir/sub still exists after the move")
+	}
+	if got := readFile(t, dst, "/sub/b.txt"); got != "b" {
+		t.Errorf("moved file = %q, want %q", got, "b")
+	}
+
+	// On the same backend the directory is renamed
+	if err := dst.Mkdir("/moved", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := Move(dstRoot+"/sub", dstRoot+"/moved", false); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, dst, "/moved/sub/b.txt"); got != "b" {
+		t.Errorf("renamed file = %q, want %q", got, "b")
+	}
+	if err := Move(srcRoot+"/dir/a.txt", dstRoot+"/dir", false); err == nil {
+		t.Errorf("moving over an existing file without overwrite succeeded")
+	}
+}
@@ -0,0 +1,80 @@
+package vfs
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+const (
+	RemoteWebDAV = "webdav"
+	RemoteS3     = "s3"
+)
+
+var RemoteTypes = []string{RemoteWebDAV, RemoteS3}
+
+// Remote is a storage configured by name, shown in the panels as
+// type://name/path. The values can reference environment variables, like
+// "$DAV_PASSWORD".
+type Remote struct {
+	Type string `toml:"type"`
+	// URL is the address of the WebDAV server or the S3 endpoint
+	URL       string `toml:"url"`
+	User      string `toml:"user"`
+	Password  string `toml:"password"`
+	Bucket    string `toml:"bucket"`
+	Region    string `toml:"region"`
+	AccessKey string `toml:"access_key"`
+	SecretKey string `toml:"secret_key"`
+}
+
+var (
+	remotesMu sync.Mutex
+	remotes   = map[string]Remote{}
+)
+
+// SetRemotes sets the storages configured by name.
+func SetRemotes(r map[string]Remote) {
+	remotesMu.Lock()
+	defer remotesMu.Unlock()
+	remotes = r
+}
+
+// lookupRemote returns the configured storage of the type with the name,
+// with the environment variables expanded.
+func lookupRemote(typ string, name string) (Remote, bool) {
+	remotesMu.Lock()
+	r, ok := remotes[name]
+	remotesMu.Unlock()
+	if !ok || r.Type != typ {
+		return Remote{}, false
+	}
+	for _, v := range []*string{&r.URL, &r.User, &r.Password, &r.Bucket, &r.Region, &r.AccessKey, &r.SecretKey} {
+		*v = os.ExpandEnv(*v)
+	}
+	return r, true
+}
+
+// uploadWriter streams the writes to an upload running in the background,
+// Close returns the result of the upload.
+type uploadWriter struct {
+	*io.PipeWriter
+	done chan error
+}
+
+func upload(put func(r io.Reader) error) io.WriteCloser {
+	pr, pw := io.Pipe()
+	w := &uploadWriter{PipeWriter: pw, done: make(chan error, 1)}
+	go func() {
+		err := put(pr)
+		// Unblock the writes when the upload stops early
+		pr.CloseWithError(err)
+		w.done <- err
+	}()
+	return w
+}
+
+func (w *uploadWriter) Close() error {
+	w.PipeWriter.Close()
+	return <-w.done
+}
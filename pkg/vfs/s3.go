@@ -0,0 +1,293 @@
+package vfs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// s3PartSize is the size of the parts of the uploads of unknown length,
+// buffered in memory.
+const s3PartSize = 16 << 20
+
+// S3 is a bucket of an S3-compatible storage, the prefixes of the keys ending
+// with "/" being shown as directories. The empty directories are kept with
+// an empty object named like the prefix.
+type S3 struct {
+	client *minio.Client
+	bucket string
+}
+
+func NewS3(client *minio.Client, bucket string) *S3 {
+	return &S3{client: client, bucket: bucket}
+}
+
+func init() {
+	Register(RemoteS3, DialS3)
+}
+
+// DialS3 connects to the bucket of a root like s3://name, name being a
+// remote of the configuration or a bucket reached with the AWS environment
+// variables.
+func DialS3(root string) (FS, error) {
+	name := root[len(RemoteS3+"://"):]
+	r, ok := lookupRemote(RemoteS3, name)
+	if !ok {
+		r = Remote{Bucket: name, URL: os.Getenv("AWS_ENDPOINT_URL"), Region: os.Getenv("AWS_REGION")}
+	}
+	if r.URL == "" {
+		r.URL = "https://s3.amazonaws.com"
+	}
+
+	endpoint, err := url.Parse(r.URL)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint %v", r.URL)
+	}
+	creds := credentials.NewEnvAWS()
+	if r.AccessKey != "" {
+		creds = credentials.NewStaticV4(r.AccessKey, r.SecretKey, "")
+	}
+	client, err := minio.New(endpoint.Host, &minio.Options{
+		Creds:  creds,
+		Secure: endpoint.Scheme == "https",
+		Region: r.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to %v: %v", r.URL, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	exists, err := client.BucketExists(ctx, r.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to %v: %v", r.URL, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("bucket %v not found", r.Bucket)
+	}
+	return &S3{client: client, bucket: r.Bucket}, nil
+}
+
+type s3Info struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+}
+
+func (i *s3Info) Name() string       { return i.name }
+func (i *s3Info) Size() int64        { return i.size }
+func (i *s3Info) ModTime() time.Time { return i.modTime }
+func (i *s3Info) IsDir() bool        { return i.dir }
+func (i *s3Info) Sys() any           { return nil }
+
+func (i *s3Info) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0o755
+	}
+	return 0o644
+}
+
+// s3Key returns the key of a path, "" for the root.
+func s3Key(p string) string {
+	return strings.TrimPrefix(path.Clean("/"+p), "/")
+}
+
+// s3Prefix returns the prefix of the keys in the directory of a path.
+func s3Prefix(p string) string {
+	if key := s3Key(p); key != "" {
+		return key + "/"
+	}
+	return ""
+}
+
+func s3NotExist(op string, p string) error {
+	return &fs.PathError{Op: op, Path: p, Err: fs.ErrNotExist}
+}
+
+// list returns up to limit objects with the prefix, all of them when limit
+// is 0.
+func (f *S3) list(prefix string, recursive bool, limit int) ([]minio.ObjectInfo, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	objects := []minio.ObjectInfo{}
+	for obj := range f.client.ListObjects(ctx, f.bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: recursive}) {
+		if obj.Err != nil {
+			return nil, obj.Err
+		}
+		objects = append(objects, obj)
+		if limit > 0 && len(objects) >= limit {
+			break
+		}
+	}
+	return objects, nil
+}
+
+func (f *S3) Stat(p string) (fs.FileInfo, error) {
+	key := s3Key(p)
+	if key == "" {
+		return &s3Info{name: "/", dir: true}, nil
+	}
+
+	obj, err := f.client.StatObject(context.Background(), f.bucket, key, minio.StatObjectOptions{})
+	if err == nil {
+		return &s3Info{name: path.Base(key), size: obj.Size, modTime: obj.LastModified}, nil
+	}
+	if minio.ToErrorResponse(err).StatusCode != 404 {
+		return nil, err
+	}
+
+	// The directories exist as long as a key starts with their prefix
+	objects, err := f.list(key+"/", false, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(objects) == 0 {
+		return nil, s3NotExist("stat", p)
+	}
+	return &s3Info{name: path.Base(key), dir: true, modTime: objects[0].LastModified}, nil
+}
+
+func (f *S3) Lstat(p string) (fs.FileInfo, error) {
+	return f.Stat(p)
+}
+
+func (f *S3) ReadDir(p string) ([]fs.FileInfo, error) {
+	prefix := s3Prefix(p)
+	objects, err := f.list(prefix, false, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := []fs.FileInfo{}
+	seen := map[string]bool{}
+	for _, obj := range objects {
+		name := strings.TrimPrefix(obj.Key, prefix)
+		// Skip the directory itself and the markers listed with the prefixes
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		if strings.HasSuffix(name, "/") {
+			infos = append(infos, &s3Info{name: strings.TrimSuffix(name, "/"), dir: true, modTime: obj.LastModified})
+		} else {
+			infos = append(infos, &s3Info{name: name, size: obj.Size, modTime: obj.LastModified})
+		}
+	}
+	return infos, nil
+}
+
+func (f *S3) Open(p string) (io.ReadCloser, error) {
+	obj, err := f.client.GetObject(context.Background(), f.bucket, s3Key(p), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	// The object is requested lazily, stat it to report the errors now
+	if _, err := obj.Stat(); err != nil {
+		obj.Close()
+		return nil, err
+	}
+	return obj, nil
+}
+
+func (f *S3) Create(p string, perm fs.FileMode) (io.WriteCloser, error) {
+	key := s3Key(p)
+	if key == "" {
+		return nil, &fs.PathError{Op: "create", Path: p, Err: fs.ErrInvalid}
+	}
+	return upload(func(r io.Reader) error {
+		_, err := f.client.PutObject(context.Background(), f.bucket, key, r, -1, minio.PutObjectOptions{PartSize: s3PartSize})
+		return err
+	}), nil
+}
+
+func (f *S3) copyObject(from string, to string) error {
+	_, err := f.client.CopyObject(context.Background(),
+		minio.CopyDestOptions{Bucket: f.bucket, Object: to},
+		minio.CopySrcOptions{Bucket: f.bucket, Object: from})
+	return err
+}
+
+// Rename copies the objects to the new keys and removes the old ones, there
+// is no rename in S3.
+func (f *S3) Rename(from string, to string) error {
+	info, err := f.Stat(from)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		if err := f.copyObject(s3Key(from), s3Key(to)); err != nil {
+			return err
+		}
+		return f.client.RemoveObject(context.Background(), f.bucket, s3Key(from), minio.RemoveObjectOptions{})
+	}
+
+	prefix, newPrefix := s3Prefix(from), s3Prefix(to)
+	if strings.HasPrefix(newPrefix, prefix) {
+		return &fs.PathError{Op: "rename", Path: to, Err: fs.ErrInvalid}
+	}
+	objects, err := f.list(prefix, true, 0)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objects {
+		if err := f.copyObject(obj.Key, newPrefix+strings.TrimPrefix(obj.Key, prefix)); err != nil {
+			return err
+		}
+	}
+	for _, obj := range objects {
+		if err := f.client.RemoveObject(context.Background(), f.bucket, obj.Key, minio.RemoveObjectOptions{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *S3) Remove(p string) error {
+	info, err := f.Stat(p)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return f.client.RemoveObject(context.Background(), f.bucket, s3Key(p), minio.RemoveObjectOptions{})
+	}
+
+	// Only the empty directories are removed, with their marker
+	prefix := s3Prefix(p)
+	if prefix == "" {
+		return &fs.PathError{Op: "remove", Path: p, Err: fs.ErrInvalid}
+	}
+	objects, err := f.list(prefix, true, 2)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objects {
+		if obj.Key != prefix {
+			return &fs.PathError{Op: "remove", Path: p, Err: ErrNotEmpty}
+		}
+	}
+	return f.client.RemoveObject(context.Background(), f.bucket, prefix, minio.RemoveObjectOptions{})
+}
+
+func (f *S3) Mkdir(p string, perm fs.FileMode) error {
+	if _, err := f.Stat(p); err == nil {
+		return &fs.PathError{Op: "mkdir", Path: p, Err: fs.ErrExist}
+	}
+	prefix := s3Prefix(p)
+	if prefix == "" {
+		return &fs.PathError{Op: "mkdir", Path: p, Err: fs.ErrInvalid}
+	}
+	_, err := f.client.PutObject(context.Background(), f.bucket, prefix, bytes.NewReader(nil), 0, minio.PutObjectOptions{})
+	return err
+}
@@ -0,0 +1,182 @@
+package vfs
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/johannesboyne/gofakes3"
+	"github.com/johannesboyne/gofakes3/backend/s3mem"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// newTestS3 mounts a bucket of an in-memory S3 server at s3://<test name>.
+// The server is reached over TLS, minio signing the streamed payloads of the
+// plain HTTP uploads in a way the fake server does not support.
+func newTestS3(t *testing.T) (*S3, string) {
+	t.Helper()
+	backend := s3mem.New()
+	if err := backend.CreateBucket("bucket"); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewTLSServer(gofakes3.New(backend).Server())
+	t.Cleanup(server.Close)
+
+	client, err := minio.New(strings.TrimPrefix(server.URL, "https://"), &minio.Options{
+		Creds:        credentials.NewStaticV4("key", "secret", ""),
+		Secure:       true,
+		Region:       "us-east-1",
+		Transport:    server.Client().Transport,
+		BucketLookup: minio.BucketLookupPath,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys := NewS3(client, "bucket")
+
+	root := "s3://" + strings.ToLower(strings.ReplaceAll(t.Name(), "/", "-"))
+	Mount(root, fsys)
+	return fsys, root
+}
+
+func TestS3Directories(t *testing.T) {
+	fsys, _ := newTestS3(t)
+
+	// The directories of the keys exist without markers
+	writeFile(t, fsys, "/dir/sub/a.txt", "a")
+	writeFile(t, fsys, "/b.txt", "bb")
+
+	info, err := fsys.Stat("/dir/sub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.IsDir() || info.Name() != "sub" {
+		t.Errorf("/dir/sub: dir %v, name %q", info.IsDir(), info.Name())
+	}
+	info, err = fsys.Stat("/b.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.IsDir() || info.Size() != 2 {
+		t.Errorf("/b.txt: dir %v, size %d", info.IsDir(), info.Size())
+	}
+	if _, err := fsys.Stat("/di"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Stat of a partial prefix = %v, want %v", err, fs.ErrNotExist)
+	}
+
+	if got := names(t, fsys, "/"); strings.Join(got, ",") != "b.txt,dir" {
+		t.Errorf("ReadDir of the root = %v", got)
+	}
+	if got := names(t, fsys, "/dir"); strings.Join(got, ",") != "sub" {
+		t.Errorf("ReadDir of /dir = %v", got)
+	}
+
+	// The empty directories are kept with a marker
+	if err := fsys.Mkdir("/empty", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.Mkdir("/empty", 0o755); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("Mkdir of an existing directory = %v, want %v", err, fs.ErrExist)
+	}
+	if info, err := fsys.Stat("/empty"); err != nil || !info.IsDir() {
+		t.Errorf("Stat of the empty directory = %v, %v", info, err)
+	}
+	if got := names(t, fsys, "/empty"); len(got) != 0 {
+		t.Errorf("ReadDir of the empty directory = %v", got)
+	}
+	if got := names(t, fsys, "/"); strings.Join(got, ",") != "b.txt,dir,empty" {
+		t.Errorf("ReadDir of the root = %v", got)
+	}
+
+	if err := fsys.Remove("/dir"); !errors.Is(err, ErrNotEmpty) {
+		t.Errorf("Remove of a non-empty directory = %v, want %v", err, ErrNotEmpty)
+	}
+	if err := fsys.Remove("/empty"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.Stat("/empty"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Stat of the removed directory = %v, want %v", err, fs.ErrNotExist)
+	}
+}
+
+func TestS3Rename(t *testing.T) {
+	fsys, _ := newTestS3(t)
+
+	writeFile(t, fsys, "/dir/a.txt", "a")
+	writeFile(t, fsys, "/dir/sub/b.txt", "b")
+	if err := fsys.Mkdir("/dir/empty", 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fsys.Rename("/dir", "/dir/sub/dir"); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("renaming a prefix into itself = %v, want %v", err, fs.ErrInvalid)
+	}
+	if err := fsys.Rename("/dir", "/moved"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.Stat("/dir"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Stat of the renamed prefix = %v, want %v", err, fs.ErrNotExist)
+	}
+	if got := names(t, fsys, "/moved"); strings.Join(got, ",") != "a.txt,empty,sub" {
+		t.Errorf("ReadDir of the renamed prefix = %v", got)
+	}
+	if got := readFile(t, fsys, "/moved/sub/b.txt"); got != "b" {
+		t.Errorf("renamed file = %q, want %q", got, "b")
+	}
+
+	if err := fsys.Rename("/moved/a.txt", "/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, fsys, "/a.txt"); got != "a" {
+		t.Errorf("renamed file = %q, want %q", got, "a")
+	}
+}
+
+func TestS3Create(t *testing.T) {
+	fsys, root := newTestS3(t)
+
+	// Written in pieces, the length being unknown to the upload
+	content := bytes.Repeat([]byte("0123456789"), 100000)
+	w, err := fsys.Create("/big.bin", 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < len(content); i += 4096 {
+		if _, err := w.Write(content[i:min(len(content), i+4096)]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := fsys.Stat("/big.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != int64(len(content)) {
+		t.Errorf("uploaded size = %d, want %d", info.Size(), len(content))
+	}
+	if got := readFile(t, fsys, "/big.bin"); got != string(content) {
+		t.Errorf("uploaded content differs")
+	}
+
+	if _, err := fsys.Create("/", 0o644); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("Create of the root = %v, want %v", err, fs.ErrInvalid)
+	}
+
+	// Copied through the panels
+	dst := t.TempDir()
+	if err := Copy(root+"/big.bin", dst, false); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dst, "big.bin")); err != nil || !bytes.Equal(data, content) {
+		t.Errorf("downloaded file: %d bytes, %v", len(data), err)
+	}
+}
@@ -0,0 +1,213 @@
+package vfs
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/url"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kevinburke/ssh_config"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// SFTP is a file system on a host reached over SSH, the paths are the
+// slash-separated ones of the server.
+type SFTP struct {
+	client *sftp.Client
+	conn   *ssh.Client
+}
+
+// NewSFTP uses a connected SFTP client, like one talking to an in-process
+// server.
+func NewSFTP(client *sftp.Client) *SFTP {
+	return &SFTP{client: client}
+}
+
+func init() {
+	Register("sftp", DialSFTP)
+}
+
+// DialSFTP connects to the host of a root like sftp://user@host:port, the
+// host being possibly an alias of the ssh config. The keys come from the ssh
+// agent and the identity files, the host key is checked with known_hosts.
+func DialSFTP(root string) (FS, error) {
+	u, err := url.Parse(root)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address %v: %v", root, err)
+	}
+	alias := u.Hostname()
+	if alias == "" {
+		return nil, fmt.Errorf("missing host in %v", root)
+	}
+
+	host := ssh_config.Get(alias, "HostName")
+	if host == "" {
+		host = alias
+	}
+	port := u.Port()
+	if port == "" {
+		port = ssh_config.Get(alias, "Port")
+	}
+	username := u.User.Username()
+	if username == "" {
+		username = ssh_config.Get(alias, "User")
+	}
+	if username == "" {
+		if current, err := user.Current(); err == nil {
+			username = current.Username
+		}
+	}
+
+	hostKey, err := hostKeyCallback(alias)
+	if err != nil {
+		return nil, err
+	}
+
+	// The agent is only used during the handshake
+	auth, agentConn := authMethods(alias)
+	if agentConn != nil {
+		defer agentConn.Close()
+	}
+
+	config := &ssh.ClientConfig{
+		User:            username,
+		Auth:            auth,
+		HostKeyCallback: hostKey,
+		Timeout:         15 * time.Second,
+	}
+	conn, err := ssh.Dial("tcp", net.JoinHostPort(host, port), config)
+	if err != nil {
+		return nil, err
+	}
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("error starting sftp on %v: %v", alias, err)
+	}
+	return &SFTP{client: client, conn: conn}, nil
+}
+
+func expandHome(p string) string {
+	if home, err := os.UserHomeDir(); err == nil && (p == "~" || strings.HasPrefix(p, "~/")) {
+		return filepath.Join(home, p[1:])
+	}
+	return p
+}
+
+// authMethods returns the keys of the ssh agent and the identity files
+// without a passphrase, with the connection to the agent to close.
+func authMethods(alias string) ([]ssh.AuthMethod, io.Closer) {
+	methods := []ssh.AuthMethod{}
+	var agentConn io.Closer
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		if c, err := net.Dial("unix", sock); err == nil {
+			agentConn = c
+			methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(c).Signers))
+		}
+	}
+
+	files := ssh_config.GetAll(alias, "IdentityFile")
+	files = append(files, "~/.ssh/id_ed25519", "~/.ssh/id_ecdsa", "~/.ssh/id_rsa")
+	signers := []ssh.Signer{}
+	for _, file := range files {
+		data, err := os.ReadFile(expandHome(file))
+		if err != nil {
+			continue
+		}
+		if signer, err := ssh.ParsePrivateKey(data); err == nil {
+			signers = append(signers, signer)
+		}
+	}
+	if len(signers) > 0 {
+		methods = append(methods, ssh.PublicKeys(signers...))
+	}
+	return methods, agentConn
+}
+
+func hostKeyCallback(alias string) (ssh.HostKeyCallback, error) {
+	files := []string{}
+	for _, option := range []string{"UserKnownHostsFile", "GlobalKnownHostsFile"} {
+		for _, file := range strings.Fields(ssh_config.Get(alias, option)) {
+			file = expandHome(file)
+			if _, err := os.Stat(file); err == nil {
+				files = append(files, file)
+			}
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no known_hosts file to check the key of %v, connect once with ssh", alias)
+	}
+	callback, err := knownhosts.New(files...)
+	if err != nil {
+		return nil, fmt.Errorf("error reading known_hosts: %v", err)
+	}
+	return callback, nil
+}
+
+// Getwd returns the directory the server starts in, usually the home.
+func (f *SFTP) Getwd() (string, error) {
+	return f.client.Getwd()
+}
+
+func (f *SFTP) Close() error {
+	err := f.client.Close()
+	if f.conn != nil {
+		f.conn.Close()
+	}
+	return err
+}
+
+func (f *SFTP) Stat(path string) (fs.FileInfo, error) {
+	return f.client.Stat(path)
+}
+
+func (f *SFTP) Lstat(path string) (fs.FileInfo, error) {
+	return f.client.Lstat(path)
+}
+
+func (f *SFTP) ReadDir(path string) ([]fs.FileInfo, error) {
+	return f.client.ReadDir(path)
+}
+
+func (f *SFTP) Open(path string) (io.ReadCloser, error) {
+	return f.client.Open(path)
+}
+
+func (f *SFTP) Create(path string, perm fs.FileMode) (io.WriteCloser, error) {
+	file, err := f.client.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return nil, err
+	}
+	// Not all the servers allow changing the mode
+	file.Chmod(perm)
+	return file, nil
+}
+
+func (f *SFTP) Rename(from string, to string) error {
+	// The posix rename replaces the target, when the server supports it
+	if err := f.client.PosixRename(from, to); err == nil {
+		return nil
+	}
+	return f.client.Rename(from, to)
+}
+
+func (f *SFTP) Remove(path string) error {
+	return f.client.Remove(path)
+}
+
+func (f *SFTP) Mkdir(path string, perm fs.FileMode) error {
+	if err := f.client.Mkdir(path); err != nil {
+		return err
+	}
+	f.client.Chmod(path, perm)
+	return nil
+}
@@ -0,0 +1,151 @@
+package vfs
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/sftp"
+)
+
+// newTestSFTP mounts an in-memory SFTP server at sftp://<test name>.
+func newTestSFTP(t *testing.T) (*SFTP, string) {
+	t.Helper()
+	serverConn, clientConn := net.Pipe()
+	server := sftp.NewRequestServer(serverConn, sftp.InMemHandler())
+	go server.Serve()
+
+	client, err := sftp.NewClientPipe(clientConn, clientConn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys := NewSFTP(client)
+	t.Cleanup(func() {
+		fsys.Close()
+		server.Close()
+	})
+
+	root := "sftp://" + strings.ToLower(strings.ReplaceAll(t.Name(), "/", "-"))
+	Mount(root, fsys)
+	return fsys, root
+}
+
+func TestSFTP(t *testing.T) {
+	fsys, _ := newTestSFTP(t)
+
+	if err := fsys.Mkdir("/dir", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, fsys, "/dir/a.txt", "a")
+	writeFile(t, fsys, "/dir/b.txt", "bb")
+
+	info, err := fsys.Stat("/dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.IsDir() {
+		t.Errorf("/dir is not a directory: %v", info.Mode())
+	}
+	info, err = fsys.Stat("/dir/b.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.IsDir() || info.Size() != 2 {
+		t.Errorf("/dir/b.txt: dir %v, size %d", info.IsDir(), info.Size())
+	}
+
+	if got := names(t, fsys, "/dir"); strings.Join(got, ",") != "a.txt,b.txt" {
+		t.Errorf("ReadDir = %v", got)
+	}
+
+	if err := fsys.Rename("/dir/a.txt", "/dir/c.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, fsys, "/dir/c.txt"); got != "a" {
+		t.Errorf("renamed file = %q, want %q", got, "a")
+	}
+	if _, err := fsys.Stat("/dir/a.txt"); err == nil {
+		t.Errorf("/dir/a.txt still exists after the rename")
+	}
+
+	if err := fsys.Remove("/dir/b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if got := names(t, fsys, "/dir"); strings.Join(got, ",") != "c.txt" {
+		t.Errorf("ReadDir after Remove = %v", got)
+	}
+}
+
+func TestSFTPCopy(t *testing.T) {
+	fsys, root := newTestSFTP(t)
+	local := t.TempDir()
+
+	src := filepath.Join(local, "src")
+	if err := os.MkdirAll(filepath.Join(src, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "sub", "file.txt"), []byte("local"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Local to SFTP
+	if err := Copy(src, root+"/", false); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, fsys, "/src/sub/file.txt"); got != "local" {
+		t.Errorf("uploaded file = %q, want %q", got, "local")
+	}
+
+	// SFTP to local
+	back := t.TempDir()
+	if err := Copy(root+"/src", back, false); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(back, "src", "sub", "file.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "local" {
+		t.Errorf("downloaded file = %q, want %q", data, "local")
+	}
+
+	if err := Copy(src, root+"/", false); err == nil {
+		t.Errorf("copying over an existing directory without overwrite succeeded")
+	}
+}
+
+func TestSFTPMove(t *testing.T) {
+	fsys, root := newTestSFTP(t)
+	local := t.TempDir()
+
+	src := filepath.Join(local, "file.txt")
+	if err := os.WriteFile(src, []byte("moved"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.Mkdir("/dst", 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Move(src, root+"/dst", false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Errorf("%v still exists after the move: %v", src, err)
+	}
+	if got := readFile(t, fsys, "/dst/file.txt"); got != "moved" {
+		t.Errorf("moved file = %q, want %q", got, "moved")
+	}
+
+	// Back to the local file system
+	if err := Move(root+"/dst/file.txt", local, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.Stat("/dst/file.txt"); err == nil {
+		t.Errorf("/dst/file.txt still exists after the move")
+	}
+	if data, err := os.ReadFile(src); err != nil || string(data) != "moved" {
+		t.Errorf("file moved back = %q, %v", data, err)
+	}
+}
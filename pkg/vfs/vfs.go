@@ -0,0 +1,224 @@
+package vfs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/sandrolain/gommander/pkg/archive"
+)
+
+var (
+	ErrReadOnly = errors.New("read-only file system")
+	ErrNotEmpty = errors.New("directory not empty")
+)
+
+// FS is a file system backend shown in the panels. The paths are the ones of
+// the backend: native for the local file system, slash-separated for the
+// others.
+type FS interface {
+	Stat(path string) (fs.FileInfo, error)
+	Lstat(path string) (fs.FileInfo, error)
+	ReadDir(path string) ([]fs.FileInfo, error)
+	Open(path string) (io.ReadCloser, error)
+	Create(path string, perm fs.FileMode) (io.WriteCloser, error)
+	Rename(from string, to string) error
+	Remove(path string) error
+	Mkdir(path string, perm fs.FileMode) error
+}
+
+// ReadOnlyFS is implemented by the backends refusing the changes.
+type ReadOnlyFS interface {
+	ReadOnly() bool
+}
+
+// DirSizer is implemented by the backends knowing the total size of the
+// directories.
+type DirSizer interface {
+	DirSize(path string) (uint64, bool)
+}
+
+// Extractor is implemented by the backends copying whole trees to local
+// directories faster than file by file.
+type Extractor interface {
+	Extract(path string, dstDir string, overwrite bool) error
+}
+
+// Opener connects to the backend of a root like "mem://name".
+type Opener func(root string) (FS, error)
+
+var (
+	mu      sync.Mutex
+	openers = map[string]Opener{}
+	mounted = map[string]FS{}
+)
+
+// Register adds the backend of the paths starting with scheme://.
+func Register(scheme string, open Opener) {
+	mu.Lock()
+	defer mu.Unlock()
+	openers[scheme] = open
+}
+
+func init() {
+	Register("mem", func(root string) (FS, error) {
+		return NewMemory(), nil
+	})
+}
+
+// splitRoot splits a path like scheme://authority/dir into the root and the
+// slash-separated path, the root of the local paths is empty.
+func splitRoot(p string) (string, string) {
+	i := strings.Index(p, "://")
+	if i <= 0 || strings.ContainsAny(p[:i], `/\`) {
+		return "", p
+	}
+	rest := p[i+3:]
+	j := strings.Index(rest, "/")
+	if j < 0 {
+		return p, "/"
+	}
+	return p[:i+3+j], path.Clean(rest[j:])
+}
+
+// Resolve returns the backend of a path of the panels and the path in the
+// backend. The paths inside the archives resolve to the archive read-only,
+// the archives themselves being local files.
+func Resolve(p string) (FS, string, error) {
+	return resolve(p, false)
+}
+
+// ResolveDir is Resolve for the directories shown in the panels, an archive
+// resolving to its root.
+func ResolveDir(p string) (FS, string, error) {
+	return resolve(p, true)
+}
+
+func resolve(p string, dir bool) (FS, string, error) {
+	root, rest := splitRoot(p)
+	if root == "" {
+		arc, inner, ok := archive.Split(p)
+		if !ok && dir && archive.IsArchive(p) {
+			arc, inner, ok = p, "", true
+		}
+		if ok {
+			a, err := archive.Open(arc)
+			if err != nil {
+				return nil, "", err
+			}
+			return &archiveFS{a: a}, inner, nil
+		}
+		return Local{}, p, nil
+	}
+
+	mu.Lock()
+	fsys, ok := mounted[root]
+	scheme := root[:strings.Index(root, "://")]
+	open, known := openers[scheme]
+	mu.Unlock()
+	if ok {
+		return fsys, rest, nil
+	}
+	if !known {
+		return nil, "", fmt.Errorf("unsupported file system %v", scheme)
+	}
+
+	// Connect without holding the lock, the remote backends may be slow
+	fsys, err := open(root)
+	if err != nil {
+		return nil, "", err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if other, ok := mounted[root]; ok {
+		if c, ok := fsys.(io.Closer); ok {
+			c.Close()
+		}
+		return other, rest, nil
+	}
+	mounted[root] = fsys
+	return fsys, rest, nil
+}
+
+// Mount uses the backend for the paths starting with root, like an already
+// connected client.
+func Mount(root string, fsys FS) {
+	mu.Lock()
+	defer mu.Unlock()
+	mounted[root] = fsys
+}
+
+// Root returns the root of a path of another backend, like sftp://host, and
+// "" for the local paths, the ones inside archives included.
+func Root(p string) string {
+	root, _ := splitRoot(p)
+	return root
+}
+
+// IsLocal reports whether the path is on the local file system, outside of
+// the archives.
+func IsLocal(p string) bool {
+	root, _ := splitRoot(p)
+	if root != "" {
+		return false
+	}
+	_, _, ok := archive.Split(p)
+	return !ok
+}
+
+// IsArchive reports whether the path is a local archive that can be shown as
+// a directory.
+func IsArchive(p string) bool {
+	root, _ := splitRoot(p)
+	return root == "" && archive.IsArchive(p)
+}
+
+// ReadOnly reports whether the path is on a backend refusing the changes.
+func ReadOnly(p string) bool {
+	fsys, _, err := Resolve(p)
+	if err != nil {
+		return false
+	}
+	ro, ok := fsys.(ReadOnlyFS)
+	return ok && ro.ReadOnly()
+}
+
+// Join joins the elements to a path of the panels.
+func Join(dir string, elem ...string) string {
+	root, rest := splitRoot(dir)
+	if root == "" {
+		return filepath.Join(append([]string{dir}, elem...)...)
+	}
+	return root + path.Join(append([]string{rest}, elem...)...)
+}
+
+// Dir returns the parent of a path of the panels.
+func Dir(p string) string {
+	root, rest := splitRoot(p)
+	if root == "" {
+		return filepath.Dir(p)
+	}
+	return root + path.Dir(rest)
+}
+
+// Base returns the last element of a path of the panels.
+func Base(p string) string {
+	root, rest := splitRoot(p)
+	if root == "" {
+		return filepath.Base(p)
+	}
+	return path.Base(rest)
+}
+
+// JoinIn joins the elements to a path of the backend.
+func JoinIn(fsys FS, elem ...string) string {
+	if _, ok := fsys.(Local); ok {
+		return filepath.Join(elem...)
+	}
+	return path.Join(elem...)
+}
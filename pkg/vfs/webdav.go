@@ -0,0 +1,93 @@
+package vfs
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"time"
+
+	"github.com/studio-b12/gowebdav"
+)
+
+// WebDAV is a file system on a WebDAV server, the paths are the
+// slash-separated ones under the address of the server.
+type WebDAV struct {
+	client *gowebdav.Client
+}
+
+func NewWebDAV(client *gowebdav.Client) *WebDAV {
+	return &WebDAV{client: client}
+}
+
+func init() {
+	Register(RemoteWebDAV, DialWebDAV)
+}
+
+// DialWebDAV connects to the server of a root like webdav://name, name being
+// a remote of the configuration or the host of an HTTPS server.
+func DialWebDAV(root string) (FS, error) {
+	name := root[len(RemoteWebDAV+"://"):]
+	r, ok := lookupRemote(RemoteWebDAV, name)
+	if !ok {
+		r = Remote{URL: "https://" + name}
+	}
+
+	client := gowebdav.NewClient(r.URL, r.User, r.Password)
+	client.SetTimeout(30 * time.Second)
+	if err := client.Connect(); err != nil {
+		return nil, fmt.Errorf("error connecting to %v: %v", r.URL, err)
+	}
+	return &WebDAV{client: client}, nil
+}
+
+func (f *WebDAV) Stat(path string) (fs.FileInfo, error) {
+	return f.client.Stat(path)
+}
+
+func (f *WebDAV) Lstat(path string) (fs.FileInfo, error) {
+	return f.client.Stat(path)
+}
+
+func (f *WebDAV) ReadDir(path string) ([]fs.FileInfo, error) {
+	return f.client.ReadDir(path)
+}
+
+func (f *WebDAV) Open(path string) (io.ReadCloser, error) {
+	return f.client.ReadStream(path)
+}
+
+func (f *WebDAV) Create(path string, perm fs.FileMode) (io.WriteCloser, error) {
+	// The content is sent chunked, without knowing its length
+	return upload(func(r io.Reader) error {
+		return f.client.WriteStreamWithLength(path, r, -1, perm)
+	}), nil
+}
+
+func (f *WebDAV) Rename(from string, to string) error {
+	return f.client.Rename(from, to, true)
+}
+
+func (f *WebDAV) Remove(path string) error {
+	// The server removes the collections with their content
+	info, err := f.client.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		entries, err := f.client.ReadDir(path)
+		if err != nil {
+			return err
+		}
+		if len(entries) > 0 {
+			return &fs.PathError{Op: "remove", Path: path, Err: ErrNotEmpty}
+		}
+	}
+	return f.client.Remove(path)
+}
+
+func (f *WebDAV) Mkdir(path string, perm fs.FileMode) error {
+	if _, err := f.client.Stat(path); err == nil {
+		return &fs.PathError{Op: "mkdir", Path: path, Err: fs.ErrExist}
+	}
+	return f.client.Mkdir(path, perm)
+}
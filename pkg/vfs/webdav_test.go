@@ -0,0 +1,106 @@
+package vfs
+
+import (
+	"errors"
+	"io/fs"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/studio-b12/gowebdav"
+	"golang.org/x/net/webdav"
+)
+
+// newTestWebDAV mounts an in-memory WebDAV server at webdav://<test name>.
+func newTestWebDAV(t *testing.T) (*WebDAV, string) {
+	t.Helper()
+	server := httptest.NewServer(&webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	})
+	t.Cleanup(server.Close)
+
+	fsys := NewWebDAV(gowebdav.NewClient(server.URL, "", ""))
+	root := "webdav://" + strings.ToLower(strings.ReplaceAll(t.Name(), "/", "-"))
+	Mount(root, fsys)
+	return fsys, root
+}
+
+func TestWebDAV(t *testing.T) {
+	fsys, _ := newTestWebDAV(t)
+
+	if err := fsys.Mkdir("/dir", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.Mkdir("/dir", 0o755); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("Mkdir of an existing directory = %v, want %v", err, fs.ErrExist)
+	}
+	writeFile(t, fsys, "/dir/a.txt", "a")
+	writeFile(t, fsys, "/dir/b.txt", "bb")
+
+	info, err := fsys.Stat("/dir/b.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.IsDir() || info.Size() != 2 {
+		t.Errorf("/dir/b.txt: dir %v, size %d", info.IsDir(), info.Size())
+	}
+	if got := names(t, fsys, "/dir"); strings.Join(got, ",") != "a.txt,b.txt" {
+		t.Errorf("ReadDir = %v", got)
+	}
+
+	if err := fsys.Remove("/dir"); !errors.Is(err, ErrNotEmpty) {
+		t.Errorf("Remove of a non-empty directory = %v, want %v", err, ErrNotEmpty)
+	}
+
+	if err := fsys.Rename("/dir", "/moved"); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, fsys, "/moved/a.txt"); got != "a" {
+		t.Errorf("renamed file = %q, want %q", got, "a")
+	}
+	if _, err := fsys.Stat("/dir"); err == nil {
+		t.Errorf("/dir still exists after the rename")
+	}
+
+	for _, p := range []string{"/moved/a.txt", "/moved/b.txt", "/moved"} {
+		if err := fsys.Remove(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := names(t, fsys, "/"); len(got) != 0 {
+		t.Errorf("ReadDir after Remove = %v", got)
+	}
+}
+
+func TestWebDAVCopy(t *testing.T) {
+	fsys, root := newTestWebDAV(t)
+
+	src := filepath.Join(t.TempDir(), "src")
+	if err := os.MkdirAll(filepath.Join(src, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "sub", "file.txt"), []byte("local"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Move(src, root+"/", false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Errorf("%v still exists after the move: %v", src, err)
+	}
+	if got := readFile(t, fsys, "/src/sub/file.txt"); got != "local" {
+		t.Errorf("uploaded file = %q, want %q", got, "local")
+	}
+
+	dst := t.TempDir()
+	if err := Copy(root+"/src", dst, false); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dst, "src", "sub", "file.txt")); err != nil || string(data) != "local" {
+		t.Errorf("downloaded file = %q, %v", data, err)
+	}
+}